@@ -160,6 +160,7 @@ func (s *BearerToken) Context(ctx *baseContext.Context) {
 			ctx.Next()
 			return
 		}
+		ctx.RecordEvent(baseContext.EventAuthFailure, "bearerToken")
 		ctx.Error(err)
 		return
 	}
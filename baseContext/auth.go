@@ -0,0 +1,48 @@
+package baseContext
+
+type AuthReporter interface {
+	Check(ctx *Context, account string) error
+	Failure(ctx *Context, account string) error
+	Success(ctx *Context, account string)
+}
+
+const authReporterContextKey = "authReporter"
+
+func (ctx *Context) SetAuthReporter(reporter AuthReporter) {
+	ctx.Values().Set(authReporterContextKey, reporter)
+}
+
+func (ctx *Context) GetAuthReporter() AuthReporter {
+	if v := ctx.Values().Get(authReporterContextKey); v != nil {
+		if reporter, ok := v.(AuthReporter); ok {
+			return reporter
+		}
+	}
+	return nil
+}
+
+// CheckAuthLock returns the lockout error when the account or the client ip is currently locked.
+func (ctx *Context) CheckAuthLock(account string) error {
+	if reporter := ctx.GetAuthReporter(); reporter != nil {
+		if err := reporter.Check(ctx, account); err != nil {
+			ctx.RecordEvent(EventLimiterRejection, "lockout")
+			return err
+		}
+	}
+	return nil
+}
+
+// ReportAuthFailure records a failed login/verification, it returns the lockout error once the limit is reached.
+func (ctx *Context) ReportAuthFailure(account string) error {
+	ctx.RecordEvent(EventAuthFailure, "credentials")
+	if reporter := ctx.GetAuthReporter(); reporter != nil {
+		return reporter.Failure(ctx, account)
+	}
+	return nil
+}
+
+func (ctx *Context) ReportAuthSuccess(account string) {
+	if reporter := ctx.GetAuthReporter(); reporter != nil {
+		reporter.Success(ctx, account)
+	}
+}
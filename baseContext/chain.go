@@ -0,0 +1,45 @@
+package baseContext
+
+import (
+	"errors"
+	baseError "github.com/go-tron/base-error"
+	"strings"
+)
+
+const ChainSeparator = "<-"
+
+// AppendChain appends the hops to the chain, which reads from the failing service to the edge, e.g. order<-gateway.
+func AppendChain(chain string, hops ...string) string {
+	for _, hop := range hops {
+		if hop == "" {
+			continue
+		}
+		if chain == "" {
+			chain = hop
+			continue
+		}
+		//already wrapped by this hop
+		if chain == hop || strings.HasSuffix(chain, ChainSeparator+hop) {
+			continue
+		}
+		chain += ChainSeparator + hop
+	}
+	return chain
+}
+
+// ChainError returns a copy of a downstream error with the application name appended to its chain, code, message and system flag are kept.
+// service names the downstream and starts the chain when the downstream didn't report one, other errors are returned unchanged.
+func (ctx *Context) ChainError(err error, service string) error {
+	var e *baseError.Error
+	if !errors.As(err, &e) {
+		return err
+	}
+	wrapped := baseError.New(e.Code, e.Msg)
+	wrapped.System = e.System
+	if e.Chain == "" {
+		wrapped.Chain = AppendChain(service, ctx.ApplicationName)
+	} else {
+		wrapped.Chain = AppendChain(e.Chain, ctx.ApplicationName)
+	}
+	return wrapped
+}
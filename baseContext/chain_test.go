@@ -0,0 +1,53 @@
+package baseContext_test
+
+import (
+	"errors"
+	baseError "github.com/go-tron/base-error"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"testing"
+)
+
+func TestAppendChain(t *testing.T) {
+	for _, c := range []struct {
+		chain string
+		hops  []string
+		want  string
+	}{
+		{"", []string{"order", "gateway"}, "order<-gateway"},
+		{"order", []string{"", "gateway"}, "order<-gateway"},
+		{"order<-gateway", []string{"gateway"}, "order<-gateway"},
+		{"gateway", []string{"gateway"}, "gateway"},
+		{"order<-api-gateway", []string{"gateway"}, "order<-api-gateway<-gateway"},
+	} {
+		if chain := baseContext.AppendChain(c.chain, c.hops...); chain != c.want {
+			t.Fatalf("%q %v: %q", c.chain, c.hops, chain)
+		}
+	}
+}
+
+func TestChainError(t *testing.T) {
+	plain := errors.New("plain")
+	app := iris.New()
+	app.Get("/", baseContext.Handler(func(ctx *baseContext.Context) {
+		downstream := baseError.System("100", "down")
+		e, ok := ctx.ChainError(downstream, "order").(*baseError.Error)
+		if !ok || e.Code != "100" || !e.System || e.Chain != "order<-gateway" || downstream.Chain != "" {
+			ctx.StatusCode(iris.StatusInternalServerError)
+			return
+		}
+		//the downstream reported its own chain
+		downstream.Chain = "stock<-order"
+		if e := ctx.ChainError(downstream, "order").(*baseError.Error); e.Chain != "stock<-order<-gateway" {
+			ctx.StatusCode(iris.StatusInternalServerError)
+			return
+		}
+		if ctx.ChainError(plain, "order") != plain {
+			ctx.StatusCode(iris.StatusInternalServerError)
+			return
+		}
+		ctx.WriteString("ok")
+	}))
+	httptest.New(t, app).GET("/").Expect().Status(iris.StatusOK).Body().IsEqual("ok")
+}
@@ -3,6 +3,7 @@ package baseContext
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	baseError "github.com/go-tron/base-error"
@@ -13,7 +14,7 @@ import (
 	"github.com/go-tron/validate"
 	"github.com/iris-contrib/schema"
 	"github.com/kataras/iris/v12"
-	"github.com/kataras/iris/v12/sessions"
+	"net/http"
 	"reflect"
 	"strings"
 )
@@ -24,6 +25,7 @@ var (
 	ErrorSession    = baseError.SystemFactory("102")
 	ErrorReadParams = baseError.Factory("1001", "params read failed:{}")
 	ErrorValidation = baseError.Factory("1002", "params validate failed:{}")
+	ErrorBodyLimit  = baseError.Factory("1003", "request body too large (limit {} bytes)")
 )
 
 type Logger interface {
@@ -88,26 +90,36 @@ func WithSystemErrorCode(val string) Option {
 	}
 }
 
-type Context struct {
-	iris.Context
-	Env             string
-	ApplicationName string
-	Internal        bool
-	Logger          Logger
-	Response        Response
-	ViewError       string
-	SystemErrorCode string
+func WithMaxJSONDepth(val int) Option {
+	return func(opts *Context) {
+		opts.MaxJSONDepth = val
+	}
 }
 
-const irisSessionContextKey = "iris.session"
+func WithMaxFormKeys(val int) Option {
+	return func(opts *Context) {
+		opts.MaxFormKeys = val
+	}
+}
 
-func (ctx *Context) GetSession() *sessions.Session {
-	if v := ctx.Values().Get(irisSessionContextKey); v != nil {
-		if sess, ok := v.(*sessions.Session); ok {
-			return sess
-		}
+func WithMaxMultipartMemory(val int64) Option {
+	return func(opts *Context) {
+		opts.MaxMultipartMemory = val
 	}
-	return nil
+}
+
+type Context struct {
+	iris.Context
+	Env                string
+	ApplicationName    string
+	Internal           bool
+	Logger             Logger
+	Response           Response
+	ViewError          string
+	SystemErrorCode    string
+	MaxJSONDepth       int
+	MaxFormKeys        int
+	MaxMultipartMemory int64
 }
 
 func UnmarshalJSON(data []byte, v interface{}) error {
@@ -118,14 +130,14 @@ func UnmarshalJSON(data []byte, v interface{}) error {
 }
 
 func (ctx *Context) ReadJSONUseNumber(p interface{}) error {
-	if err := ctx.UnmarshalBody(p, iris.UnmarshalerFunc(jsonUtil.UnmarshalUseNumber)); err != nil {
+	if err := ctx.UnmarshalBody(p, ctx.jsonUnmarshaler(jsonUtil.UnmarshalUseNumber, true)); err != nil {
 		return err
 	}
 	return nil
 }
 
 func (ctx *Context) JSONReqBody(p interface{}) error {
-	if err := ctx.UnmarshalBody(p, iris.UnmarshalerFunc(UnmarshalJSON)); err != nil {
+	if err := ctx.UnmarshalBody(p, ctx.jsonUnmarshaler(UnmarshalJSON, false)); err != nil {
 		return err
 	}
 	if reflect.TypeOf(p).Kind() == reflect.Struct || (reflect.TypeOf(p).Kind() == reflect.Ptr && reflect.TypeOf(p).Elem().Kind() == reflect.Struct) {
@@ -172,6 +184,13 @@ func (ctx *Context) Success(data ...interface{}) {
 func (ctx *Context) BaseError(err error) (e *baseError.Error) {
 	ctx.Values().Set("error", err)
 	var errorType = reflect.TypeOf(err).String()
+
+	//请求体超出限制
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		ctx.StatusCode(http.StatusRequestEntityTooLarge)
+		return ErrorBodyLimit(maxBytesError.Limit)
+	}
 	//baseError
 	if errorType == "*baseError.Error" {
 		e = err.(*baseError.Error)
@@ -217,9 +236,28 @@ func (ctx *Context) BaseError(err error) (e *baseError.Error) {
 	}
 }
 
+// PanicError returns the error rendered for a recovered panic, a panicking *baseError.Error keeps its code, anything else is a system error.
+func (ctx *Context) PanicError(v interface{}) *baseError.Error {
+	if p, ok := v.(*RecoveredPanic); ok {
+		v = p.Value
+	}
+	if e, ok := v.(*baseError.Error); ok {
+		return e
+	}
+	if ctx.SystemErrorCode != "" {
+		return baseError.System(ctx.SystemErrorCode, fmt.Sprint(v))
+	}
+	return ErrorSystem(fmt.Sprint(v))
+}
+
 func (ctx *Context) Error(err error, data ...interface{}) {
 	ctx.StopExecution()
-	e := ctx.BaseError(err)
+	ctx.WriteError(ctx.BaseError(err), data...)
+}
+
+// WriteError renders the error envelope without the logging and status handling of BaseError.
+func (ctx *Context) WriteError(e *baseError.Error, data ...interface{}) {
+	ctx.Values().Set(responseErrorContextKey, e)
 	message := e.Msg
 	if e.System && ctx.Env == config.Production.String() && !ctx.Internal {
 		message = "system error"
@@ -246,6 +284,7 @@ func (ctx *Context) Error(err error, data ...interface{}) {
 func (ctx *Context) ErrorView(err error, data ...interface{}) {
 	ctx.StopExecution()
 	e := ctx.BaseError(err)
+	ctx.Values().Set(responseErrorContextKey, e)
 	message := e.Msg
 	if e.System && ctx.Env == config.Production.String() && !ctx.Internal {
 		message = "system error"
@@ -254,7 +293,14 @@ func (ctx *Context) ErrorView(err error, data ...interface{}) {
 		message += " rid:" + requestId
 	}
 	ctx.ViewData("Message", fmt.Sprintf("[%s]%s", e.Code, message))
-	ctx.View(ctx.ViewError)
+	flashes := ctx.peekFlashes()
+	if len(flashes) > 0 {
+		ctx.ViewData("Flashes", flashes)
+	}
+	//the flashes are consumed only once an html page showed them
+	if err := ctx.View(ctx.ViewError); err == nil && len(flashes) > 0 && strings.HasPrefix(ctx.GetContentType(), "text/html") {
+		ctx.GetSessionValues().ClearStoredFlashes()
+	}
 }
 
 func (ctx *Context) GetIP() string {
@@ -368,10 +414,24 @@ func (ctx *Context) SetTraceCtx(traceCtx context.Context) {
 var formDecoder *schema.Decoder
 
 func (ctx *Context) ReadForm(p interface{}) error {
+	if ctx.MaxMultipartMemory > 0 && strings.HasPrefix(ctx.GetContentTypeRequested(), "multipart/") {
+		if err := ctx.Request().ParseMultipartForm(ctx.MaxMultipartMemory); err != nil {
+			return err
+		}
+	}
+	if ctx.MaxFormKeys > 0 {
+		if err := ctx.checkFormKeys(); err != nil {
+			return err
+		}
+	}
 	values := ctx.FormValues()
 	if len(values) == 0 {
 		return nil
 	}
+	//multipart keys are only known once parsed, the body limit and the part limit of mime/multipart bound them
+	if ctx.MaxFormKeys > 0 && len(values) > ctx.MaxFormKeys {
+		return ErrorReadParams(fmt.Sprintf("form keys exceed %d", ctx.MaxFormKeys))
+	}
 
 	if reflect.TypeOf(p).Kind() == reflect.Map || (reflect.TypeOf(p).Kind() == reflect.Ptr && reflect.TypeOf(p).Elem().Kind() == reflect.Map) {
 		pV := reflect.ValueOf(p)
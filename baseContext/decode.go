@@ -0,0 +1,105 @@
+package baseContext
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/kataras/iris/v12"
+	"io"
+	"strings"
+)
+
+const disallowUnknownFieldsContextKey = "disallowUnknownFields"
+
+// DisallowUnknownFields makes the following JSONReqBody/ReadJSONUseNumber calls of this request reject unknown fields.
+func (ctx *Context) DisallowUnknownFields() {
+	ctx.Values().Set(disallowUnknownFieldsContextKey, true)
+}
+
+func (ctx *Context) jsonUnmarshaler(unmarshal func([]byte, interface{}) error, useNumber bool) iris.UnmarshalerFunc {
+	return func(data []byte, v interface{}) error {
+		if ctx.MaxJSONDepth > 0 {
+			if err := CheckJSONDepth(data, ctx.MaxJSONDepth); err != nil {
+				return err
+			}
+		}
+		if !ctx.Values().GetBoolDefault(disallowUnknownFieldsContextKey, false) || len(data) == 0 {
+			return unmarshal(data, v)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		if useNumber {
+			decoder.UseNumber()
+		}
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(v); err != nil {
+			return ErrorReadParams(err.Error())
+		}
+		return nil
+	}
+}
+
+func CheckJSONDepth(data []byte, max int) error {
+	var (
+		depth    int
+		inString bool
+		escaped  bool
+	)
+	for _, c := range data {
+		if inString {
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > max {
+				return ErrorReadParams(fmt.Sprintf("json depth exceeds %d", max))
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return nil
+}
+
+// countFormKeys counts the pairs of an urlencoded string, an upper bound of its keys.
+func countFormKeys(data string) int {
+	var count int
+	for data != "" {
+		var pair string
+		pair, data, _ = strings.Cut(data, "&")
+		if pair != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// checkFormKeys counts the pairs of the query and of an urlencoded body before they are parsed into maps.
+func (ctx *Context) checkFormKeys() error {
+	r := ctx.Request()
+	if r.Form != nil {
+		return nil
+	}
+	keys := countFormKeys(r.URL.RawQuery)
+	if r.Body != nil && ctx.GetContentTypeRequested() == "application/x-www-form-urlencoded" {
+		data, err := ctx.GetBody()
+		if err != nil {
+			return err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		keys += countFormKeys(string(data))
+	}
+	if keys > ctx.MaxFormKeys {
+		return ErrorReadParams(fmt.Sprintf("form keys exceed %d", ctx.MaxFormKeys))
+	}
+	return nil
+}
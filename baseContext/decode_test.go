@@ -0,0 +1,63 @@
+package baseContext_test
+
+import (
+	"github.com/go-tron/iris/baseContext"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckJSONDepth(t *testing.T) {
+	if err := baseContext.CheckJSONDepth([]byte(`{"a":[{"b":"[[[[{{{{"}]}`), 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := baseContext.CheckJSONDepth([]byte(`{"a":[{"b":[1]}]}`), 3); err == nil {
+		t.Fatal("expected the depth to be rejected")
+	}
+}
+
+func newDecodeApp() *iris.Application {
+	app := iris.New()
+	app.Post("/json", baseContext.Handler(func(ctx *baseContext.Context) {
+		if ctx.URLParamExists("strict") {
+			ctx.DisallowUnknownFields()
+		}
+		var body struct {
+			Name string `json:"name"`
+		}
+		if err := ctx.JSONReqBody(&body); err != nil {
+			ctx.Error(err)
+			return
+		}
+		ctx.WriteString(body.Name)
+	}))
+	app.Post("/form", baseContext.Handler(func(ctx *baseContext.Context) {
+		form := map[string]string{}
+		if err := ctx.ReadForm(&form); err != nil {
+			ctx.Error(err)
+			return
+		}
+		ctx.WriteString(form["name"])
+	}))
+	return app
+}
+
+func TestJSONReqBody(t *testing.T) {
+	e := httptest.New(t, newDecodeApp())
+	e.POST("/json").WithBytes([]byte(`{"name":"a","extra":1}`)).Expect().Body().IsEqual("a")
+	e.POST("/json").WithQuery("strict", 1).WithBytes([]byte(`{"name":"a","extra":1}`)).Expect().
+		JSON().Object().Value("code").IsEqual("1001")
+	e.POST("/json").WithBytes([]byte(`{"name":[[[["a"]]]]}`)).Expect().
+		JSON().Object().Value("code").IsEqual("1001")
+}
+
+func TestReadFormKeys(t *testing.T) {
+	e := httptest.New(t, newDecodeApp())
+	e.POST("/form").WithFormField("name", "a").WithFormField("b", "1").Expect().Body().IsEqual("a")
+	e.POST("/form").WithHeader("Content-Type", "application/x-www-form-urlencoded").
+		WithBytes([]byte("name=a&" + strings.Repeat("k=1&", 3))).Expect().
+		JSON().Object().Value("code").IsEqual("1001")
+	e.POST("/form").WithQuery("q1", 1).WithQuery("q2", 1).WithFormField("name", "a").WithFormField("b", "1").Expect().
+		JSON().Object().Value("code").IsEqual("1001")
+}
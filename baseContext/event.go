@@ -0,0 +1,51 @@
+package baseContext
+
+import (
+	baseError "github.com/go-tron/base-error"
+)
+
+const (
+	EventLimiterRejection = "limiterRejection"
+	EventAuthFailure      = "authFailure"
+	EventPanic            = "panic"
+)
+
+// EventRecorder counts the events of the security middlewares, it is set by the metrics middleware which must run before them.
+type EventRecorder interface {
+	RecordEvent(ctx *Context, event string, reason string)
+}
+
+const (
+	eventRecorderContextKey = "eventRecorder"
+	responseErrorContextKey = "responseError"
+)
+
+func (ctx *Context) SetEventRecorder(recorder EventRecorder) {
+	ctx.Values().Set(eventRecorderContextKey, recorder)
+}
+
+func (ctx *Context) GetEventRecorder() EventRecorder {
+	if v := ctx.Values().Get(eventRecorderContextKey); v != nil {
+		if recorder, ok := v.(EventRecorder); ok {
+			return recorder
+		}
+	}
+	return nil
+}
+
+// RecordEvent reason tells which limiter rejected or why the authentication failed.
+func (ctx *Context) RecordEvent(event string, reason string) {
+	if recorder := ctx.GetEventRecorder(); recorder != nil {
+		recorder.RecordEvent(ctx, event, reason)
+	}
+}
+
+// GetResponseError returns the error rendered by Error, ErrorView or WriteError, nil when the request didn't fail.
+func (ctx *Context) GetResponseError() *baseError.Error {
+	if v := ctx.Values().Get(responseErrorContextKey); v != nil {
+		if e, ok := v.(*baseError.Error); ok {
+			return e
+		}
+	}
+	return nil
+}
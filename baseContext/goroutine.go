@@ -0,0 +1,197 @@
+package baseContext
+
+import (
+	"context"
+	"fmt"
+	baseError "github.com/go-tron/base-error"
+	"github.com/kataras/golog"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+var (
+	ErrorPanic = baseError.SystemFactory("103", "goroutine panic:{}")
+)
+
+const goGroupContextKey = "goGroup"
+
+// Group runs goroutines on behalf of a request, panics are recovered into stack errors and the trace context is propagated.
+// The goroutines must not use the *Context itself, it is reused once the handler returns.
+type Group struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	errs      []error
+	collected []error
+	err       error
+	waiting   bool
+	failFast  bool
+	requestId string
+	logger    *golog.Logger
+}
+
+func (ctx *Context) newGroup(goCtx context.Context, failFast bool) *Group {
+	g := &Group{
+		failFast:  failFast,
+		requestId: ctx.Values().GetString("requestId"),
+		logger:    ctx.Application().Logger(),
+	}
+	g.ctx, g.cancel = context.WithCancel(goCtx)
+	return g
+}
+
+// Group returns an errgroup-style group bound to the request, the first error cancels the others.
+func (ctx *Context) Group() *Group {
+	return ctx.newGroup(ctx.GetTraceCtx(), true)
+}
+
+// Go runs fn detached from the request cancellation, e.g. async notifications, errors don't cancel each other.
+// Call Wait to hold the response until they finish.
+func (ctx *Context) Go(fn func(context.Context) error) {
+	g, ok := ctx.Values().Get(goGroupContextKey).(*Group)
+	if !ok {
+		g = ctx.newGroup(context.WithoutCancel(ctx.GetTraceCtx()), false)
+		ctx.Values().Set(goGroupContextKey, g)
+	}
+	g.Go(fn)
+}
+
+// Wait waits for the goroutines started by ctx.Go and attaches their errors to the request log.
+func (ctx *Context) Wait() error {
+	g, ok := ctx.Values().Get(goGroupContextKey).(*Group)
+	if !ok {
+		return nil
+	}
+	//the group is cancelled by Wait, later calls to Go start a new one
+	ctx.Values().Remove(goGroupContextKey)
+	err := g.Wait()
+	ctx.attachGoErrors(g.takeCollected())
+	return err
+}
+
+// WaitGroup waits for g and attaches its errors to the request log, call it from the handler goroutine.
+func (ctx *Context) WaitGroup(g *Group) error {
+	err := g.Wait()
+	ctx.attachGoErrors(g.takeCollected())
+	return err
+}
+
+func (ctx *Context) attachGoErrors(errs []error) {
+	if len(errs) == 0 || ctx.Logger == nil {
+		return
+	}
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	ctx.AddLogField("goroutineErrors", messages)
+}
+
+// CallSafely runs fn and swallows its panic, used for hooks and reporters since a failing one must not take the process down.
+func CallSafely(fn func()) {
+	defer func() {
+		recover()
+	}()
+	fn()
+}
+
+// RecoveredPanic carries a panic recovered in another goroutine with the stack it happened on,
+// re-panicking it lets the recover middleware log and fingerprint the original stack rather than the one of the re-panic.
+type RecoveredPanic struct {
+	Value   interface{}
+	Stack   []byte
+	Callers []uintptr
+}
+
+// NewRecoveredPanic must be called by the deferred function that recovered v.
+func NewRecoveredPanic(v interface{}) *RecoveredPanic {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	return &RecoveredPanic{Value: v, Stack: debug.Stack(), Callers: pcs[:n]}
+}
+
+func (p *RecoveredPanic) Error() string {
+	return fmt.Sprint(p.Value)
+}
+
+func (p *RecoveredPanic) Unwrap() error {
+	err, _ := p.Value.(error)
+	return err
+}
+
+// Format prints the original stack for %+v.
+func (p *RecoveredPanic) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		fmt.Fprintf(s, "%s\n%s", p.Error(), p.Stack)
+		return
+	}
+	io.WriteString(s, p.Error())
+}
+
+func (g *Group) Go(fn func(context.Context) error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := g.run(fn); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+func (g *Group) run(fn func(context.Context) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			e := ErrorPanic(fmt.Sprint(p))
+			err = baseError.SystemStack(e.Code, e.Msg, 32)
+		}
+	}()
+	return fn(g.ctx)
+}
+
+func (g *Group) fail(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.err == nil {
+		g.err = err
+		if g.failFast {
+			g.cancel()
+		}
+	}
+	g.errs = append(g.errs, err)
+	if g.waiting {
+		g.collected = append(g.collected, err)
+	} else {
+		//nobody may ever wait for it, the request log could be written already
+		g.logger.Errorf("goroutine error rid:%s %+v", g.requestId, err)
+	}
+}
+
+// Wait returns the first error and cancels the context of the group, errors collected while waiting go to the request log, the others to the app logger.
+func (g *Group) Wait() error {
+	g.mu.Lock()
+	g.waiting = true
+	g.mu.Unlock()
+	g.wg.Wait()
+	g.cancel()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.waiting = false
+	return g.err
+}
+
+func (g *Group) takeCollected() []error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	collected := g.collected
+	g.collected = nil
+	return collected
+}
+
+func (g *Group) Errors() []error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]error(nil), g.errs...)
+}
@@ -0,0 +1,86 @@
+package baseContext_test
+
+import (
+	"context"
+	"errors"
+	baseError "github.com/go-tron/base-error"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"testing"
+	"time"
+)
+
+func newGoApp(t *testing.T) *httptest.Expect {
+	app := iris.New()
+	app.Logger().SetLevel("disable")
+	app.Get("/group", baseContext.Handler(func(ctx *baseContext.Context) {
+		g := ctx.Group()
+		cancelled := make(chan bool, 1)
+		g.Go(func(c context.Context) error {
+			select {
+			case <-c.Done():
+				cancelled <- true
+			case <-time.After(time.Second):
+				cancelled <- false
+			}
+			return nil
+		})
+		g.Go(func(c context.Context) error {
+			panic("boom")
+		})
+		err := ctx.WaitGroup(g)
+		var e *baseError.Error
+		if !errors.As(err, &e) || e.Code != "103" || !<-cancelled || len(g.Errors()) != 1 {
+			ctx.StatusCode(iris.StatusInternalServerError)
+			return
+		}
+		ctx.WriteString("ok")
+	}))
+	app.Get("/success", baseContext.Handler(func(ctx *baseContext.Context) {
+		g := ctx.Group()
+		var groupCtx context.Context
+		g.Go(func(c context.Context) error {
+			groupCtx = c
+			return nil
+		})
+		if err := g.Wait(); err != nil || groupCtx.Err() == nil {
+			ctx.StatusCode(iris.StatusInternalServerError)
+			return
+		}
+		ctx.WriteString("ok")
+	}))
+	app.Get("/go", baseContext.Handler(func(ctx *baseContext.Context) {
+		var first context.Context
+		ctx.Go(func(c context.Context) error {
+			first = c
+			return errors.New("first")
+		})
+		if err := ctx.Wait(); err == nil || err.Error() != "first" || first.Err() == nil {
+			ctx.StatusCode(iris.StatusInternalServerError)
+			return
+		}
+		//a later call starts a new group rather than reusing the cancelled one
+		var second context.Context
+		ctx.Go(func(c context.Context) error {
+			second = c
+			return nil
+		})
+		if err := ctx.Wait(); err != nil || second == first {
+			ctx.StatusCode(iris.StatusInternalServerError)
+			return
+		}
+		ctx.WriteString("ok")
+	}))
+	return httptest.New(t, app)
+}
+
+func TestGroup(t *testing.T) {
+	e := newGoApp(t)
+	e.GET("/group").Expect().Status(iris.StatusOK).Body().IsEqual("ok")
+	e.GET("/success").Expect().Status(iris.StatusOK).Body().IsEqual("ok")
+}
+
+func TestGo(t *testing.T) {
+	newGoApp(t).GET("/go").Expect().Status(iris.StatusOK).Body().IsEqual("ok")
+}
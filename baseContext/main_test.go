@@ -0,0 +1,21 @@
+package baseContext_test
+
+import (
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/response"
+	"os"
+	"testing"
+)
+
+// TestMain configures the package once, the decode tests rely on the limits and the chain tests on the application name
+// and the session tests on the error view.
+func TestMain(m *testing.M) {
+	baseContext.New("test", nil,
+		baseContext.WithResponse(response.New()),
+		baseContext.WithApplicationName("gateway"),
+		baseContext.WithMaxJSONDepth(3),
+		baseContext.WithMaxFormKeys(3),
+		baseContext.WithViewError("error.html"),
+	)
+	os.Exit(m.Run())
+}
@@ -10,13 +10,16 @@ var baseContext *Context
 
 var contextPool = sync.Pool{New: func() interface{} {
 	return &Context{
-		Env:             baseContext.Env,
-		ApplicationName: baseContext.ApplicationName,
-		Internal:        baseContext.Internal,
-		Logger:          baseContext.Logger,
-		Response:        baseContext.Response,
-		ViewError:       baseContext.ViewError,
-		SystemErrorCode: baseContext.SystemErrorCode,
+		Env:                baseContext.Env,
+		ApplicationName:    baseContext.ApplicationName,
+		Internal:           baseContext.Internal,
+		Logger:             baseContext.Logger,
+		Response:           baseContext.Response,
+		ViewError:          baseContext.ViewError,
+		SystemErrorCode:    baseContext.SystemErrorCode,
+		MaxJSONDepth:       baseContext.MaxJSONDepth,
+		MaxFormKeys:        baseContext.MaxFormKeys,
+		MaxMultipartMemory: baseContext.MaxMultipartMemory,
 	}
 }}
 
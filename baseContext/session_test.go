@@ -0,0 +1,110 @@
+package baseContext_test
+
+import (
+	"github.com/go-tron/iris/baseContext"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/sessions"
+	"net/http"
+	"testing"
+	"testing/fstest"
+)
+
+type profile struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func newSessionApp() *iris.Application {
+	app := iris.New()
+	app.Use(sessions.New(sessions.Config{Cookie: "sid"}).Handler())
+	app.Get("/values", baseContext.Handler(func(ctx *baseContext.Context) {
+		sess := ctx.GetSession()
+		//what a JSON backed store hands back
+		sess.Set("count", float64(2))
+		sess.Set("profile", map[string]interface{}{"name": "a", "age": float64(3)})
+
+		values := ctx.GetSessionValues()
+		if values.GetInt("count") != 2 || values.Increment("count", 3) != 5 || values.GetString("count") != "5" {
+			ctx.StatusCode(iris.StatusInternalServerError)
+			return
+		}
+		//the iris accessors keep their signatures
+		if _, err := sess.GetInt("missing"); err == nil {
+			ctx.StatusCode(iris.StatusInternalServerError)
+			return
+		}
+		p, err := baseContext.SessionGet[profile](ctx, "profile")
+		if err != nil || p.Name != "a" || p.Age != 3 {
+			ctx.StatusCode(iris.StatusInternalServerError)
+			return
+		}
+		if baseContext.SessionGetDefault(ctx, "missing", "def") != "def" {
+			ctx.StatusCode(iris.StatusInternalServerError)
+			return
+		}
+		ctx.WriteString("ok")
+	}))
+	app.Get("/flash", baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.SetFlash("notice", "saved")
+		ctx.GetSession().SetFlash("memory", "kept")
+	}))
+	app.Get("/read", baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.WriteString(ctx.GetFlashString("notice") + "," + ctx.GetSession().GetFlashString("memory"))
+	}))
+	return app
+}
+
+func TestSessionValues(t *testing.T) {
+	e := httptest.New(t, newSessionApp(), httptest.URL("http://example.com"))
+	e.GET("/values").Expect().Status(iris.StatusOK).Body().IsEqual("ok")
+}
+
+func TestSessionNotStarted(t *testing.T) {
+	app := iris.New()
+	app.Get("/", baseContext.Handler(func(ctx *baseContext.Context) {
+		if ctx.GetSession() != nil || ctx.GetSessionValues() != nil {
+			ctx.StatusCode(iris.StatusInternalServerError)
+			return
+		}
+		if err := ctx.SetFlash("k", "v"); err == nil {
+			ctx.StatusCode(iris.StatusInternalServerError)
+			return
+		}
+		ctx.WriteString("ok")
+	}))
+	httptest.New(t, app).GET("/").Expect().Body().IsEqual("ok")
+}
+
+func TestStoredFlash(t *testing.T) {
+	e := httptest.New(t, newSessionApp(), httptest.URL("http://example.com"))
+	e.GET("/flash").Expect().Status(iris.StatusOK)
+	e.GET("/read").Expect().Body().IsEqual("saved,kept")
+	e.GET("/read").Expect().Body().IsEqual(",")
+}
+
+func TestErrorViewFlashes(t *testing.T) {
+	for _, c := range []struct {
+		view    bool
+		flashed string
+	}{
+		{true, ""},
+		//nothing showed the flashes, they are kept
+		{false, "saved"},
+	} {
+		app := newSessionApp()
+		if c.view {
+			app.RegisterView(iris.HTML(http.FS(fstest.MapFS{"error.html": {Data: []byte("{{.Message}} {{.Flashes.notice}}")}}), ".html"))
+		}
+		app.Get("/error", baseContext.Handler(func(ctx *baseContext.Context) {
+			ctx.ErrorView(baseContext.ErrorSession("denied"))
+		}))
+		e := httptest.New(t, app, httptest.URL("http://example.com"))
+		e.GET("/flash").Expect().Status(iris.StatusOK)
+		resp := e.GET("/error").Expect()
+		if c.view {
+			resp.Body().Contains("saved")
+		}
+		e.GET("/read").Expect().Body().HasPrefix(c.flashed + ",")
+	}
+}
@@ -0,0 +1,160 @@
+package baseContext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	baseError "github.com/go-tron/base-error"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const spanInstrumentationName = "github.com/go-tron/iris/baseContext"
+
+// Span is a child span of the request, it records to the OpenTelemetry and/or OpenTracing span found in the trace ctx
+// and does nothing when tracing isn't configured.
+type Span struct {
+	ctx  context.Context
+	otel oteltrace.Span
+	ot   opentracing.Span
+}
+
+func startSpan(parent context.Context, name string) *Span {
+	s := &Span{ctx: parent}
+	if p := oteltrace.SpanFromContext(parent); p.SpanContext().IsValid() {
+		s.ctx, s.otel = p.TracerProvider().Tracer(spanInstrumentationName).Start(s.ctx, name, oteltrace.WithSpanKind(oteltrace.SpanKindInternal))
+	}
+	if p := opentracing.SpanFromContext(parent); p != nil {
+		s.ot = p.Tracer().StartSpan(name, opentracing.ChildOf(p.Context()))
+		s.ctx = opentracing.ContextWithSpan(s.ctx, s.ot)
+	}
+	return s
+}
+
+// StartSpan starts a child span of the request, pass span.Context() to the calls it covers and End it when done.
+func (ctx *Context) StartSpan(name string) *Span {
+	return startSpan(ctx.GetTraceCtx(), name)
+}
+
+// StartSpan starts a child span of this span.
+func (s *Span) StartSpan(name string) *Span {
+	return startSpan(s.ctx, name)
+}
+
+// Context carries the span, nested spans and outgoing calls made with it are its children.
+func (s *Span) Context() context.Context {
+	return s.ctx
+}
+
+func (s *Span) Recording() bool {
+	return s.otel != nil || s.ot != nil
+}
+
+func attributeOf(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case []string:
+		return attribute.StringSlice(key, v)
+	case fmt.Stringer:
+		return attribute.Stringer(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
+
+func (s *Span) SetAttribute(key string, value interface{}) *Span {
+	if s.otel != nil {
+		s.otel.SetAttributes(attributeOf(key, value))
+	}
+	if s.ot != nil {
+		s.ot.SetTag(key, value)
+	}
+	return s
+}
+
+func (s *Span) SetAttributes(attrs map[string]interface{}) *Span {
+	for key, value := range attrs {
+		s.SetAttribute(key, value)
+	}
+	return s
+}
+
+func (s *Span) AddEvent(name string, attrs map[string]interface{}) *Span {
+	if s.otel != nil {
+		kvs := make([]attribute.KeyValue, 0, len(attrs))
+		for key, value := range attrs {
+			kvs = append(kvs, attributeOf(key, value))
+		}
+		s.otel.AddEvent(name, oteltrace.WithAttributes(kvs...))
+	}
+	if s.ot != nil {
+		fields := make([]log.Field, 0, len(attrs)+1)
+		fields = append(fields, log.String("event", name))
+		for key, value := range attrs {
+			fields = append(fields, log.Object(key, value))
+		}
+		s.ot.LogFields(fields...)
+	}
+	return s
+}
+
+// RecordError follows the baseError distinction, business errors are events and only system errors mark the span as failed.
+func (s *Span) RecordError(err error) *Span {
+	if err == nil {
+		return s
+	}
+	if s.otel != nil {
+		RecordSpanError(s.otel, err)
+	}
+	if s.ot != nil {
+		if e, ok := businessError(err); ok {
+			s.ot.LogFields(log.String("event", "error"), log.String("error.code", e.Code), log.String("error.message", e.Msg))
+		} else {
+			ext.LogError(s.ot, err)
+		}
+	}
+	return s
+}
+
+// RecordSpanError records err on an OpenTelemetry span the way Span.RecordError does, used by the tracing middleware.
+func RecordSpanError(span oteltrace.Span, err error) {
+	if e, ok := businessError(err); ok {
+		span.AddEvent("error", oteltrace.WithAttributes(
+			attribute.String("error.code", e.Code),
+			attribute.String("error.message", e.Msg),
+		))
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+func businessError(err error) (*baseError.Error, bool) {
+	var e *baseError.Error
+	if errors.As(err, &e) && !e.System {
+		return e, true
+	}
+	return nil, false
+}
+
+func (s *Span) End() {
+	if s.otel != nil {
+		s.otel.End()
+	}
+	if s.ot != nil {
+		s.ot.Finish()
+	}
+}
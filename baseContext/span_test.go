@@ -0,0 +1,89 @@
+package baseContext_test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func runSpans(t *testing.T, traceCtx func(ctx context.Context) context.Context) {
+	app := iris.New()
+	app.Get("/", baseContext.Handler(func(ctx *baseContext.Context) {
+		if traceCtx != nil {
+			ctx.SetTraceCtx(traceCtx(ctx.GetTraceCtx()))
+		}
+		span := ctx.StartSpan("load")
+		span.SetAttributes(map[string]interface{}{"user.id": 1, "cache": true})
+		span.AddEvent("hit", map[string]interface{}{"key": "a"})
+		child := span.StartSpan("query")
+		child.RecordError(baseContext.ErrorReadParams("name"))
+		child.End()
+		span.RecordError(errors.New("db down"))
+		span.End()
+		ctx.WriteString("ok")
+	}))
+	httptest.New(t, app).GET("/").Expect().Status(iris.StatusOK).Body().IsEqual("ok")
+}
+
+func TestSpanWithoutTracing(t *testing.T) {
+	app := iris.New()
+	app.Get("/", baseContext.Handler(func(ctx *baseContext.Context) {
+		if ctx.StartSpan("load").Recording() {
+			ctx.StatusCode(iris.StatusInternalServerError)
+		}
+	}))
+	httptest.New(t, app).GET("/").Expect().Status(iris.StatusOK)
+	runSpans(t, nil)
+}
+
+func TestOtelSpan(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(context.Background())
+
+	runSpans(t, func(ctx context.Context) context.Context {
+		ctx, _ = provider.Tracer("test").Start(ctx, "request")
+		return ctx
+	})
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("spans %d", len(spans))
+	}
+	query, load := spans[0], spans[1]
+	if query.Name != "query" || query.Parent.SpanID() != load.SpanContext.SpanID() {
+		t.Fatalf("query %+v", query)
+	}
+	//business errors are events only
+	if query.Status.Code != codes.Unset || len(query.Events) != 1 || query.Events[0].Name != "error" {
+		t.Fatalf("query %+v %+v", query.Status, query.Events)
+	}
+	if load.Status.Code != codes.Error || len(load.Attributes) != 2 || len(load.Events) != 2 {
+		t.Fatalf("load %+v %+v %+v", load.Status, load.Attributes, load.Events)
+	}
+}
+
+func TestOpenTracingSpan(t *testing.T) {
+	tracer := mocktracer.New()
+	runSpans(t, func(ctx context.Context) context.Context {
+		return opentracing.ContextWithSpan(ctx, tracer.StartSpan("request"))
+	})
+	spans := tracer.FinishedSpans()
+	if len(spans) != 2 {
+		t.Fatalf("spans %d", len(spans))
+	}
+	query, load := spans[0], spans[1]
+	if query.OperationName != "query" || query.ParentID != load.SpanContext.SpanID || query.Tag("error") != nil {
+		t.Fatalf("query %+v", query)
+	}
+	if load.Tag("user.id") != 1 || load.Tag("error") != true {
+		t.Fatalf("load %+v", load.Tags())
+	}
+}
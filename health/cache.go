@@ -0,0 +1,141 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Transition struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	At    string `json:"at"`
+	Error string `json:"error,omitempty"`
+}
+
+type checkState struct {
+	mu      sync.Mutex
+	last    *CheckResult
+	lastAt  time.Time
+	history []*Transition
+	cancel  context.CancelFunc
+	//runMu lets concurrent probes share one run of a cached check
+	runMu sync.Mutex
+}
+
+func (s *checkState) record(result *CheckResult, at time.Time, size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	from := ""
+	if s.last != nil {
+		from = s.last.Status
+	}
+	if from != result.Status && size > 0 {
+		s.history = append(s.history, &Transition{
+			From:  from,
+			To:    result.Status,
+			At:    at.Format(time.RFC3339Nano),
+			Error: result.Error,
+		})
+		if len(s.history) > size {
+			s.history = s.history[len(s.history)-size:]
+		}
+	}
+	last := *result
+	s.last = &last
+	s.lastAt = at
+}
+
+// snapshot returns a copy of the last result carrying the transition history.
+func (s *checkState) snapshot() (*CheckResult, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last == nil {
+		return nil, time.Time{}
+	}
+	result := *s.last
+	result.History = append([]*Transition(nil), s.history...)
+	return &result, s.lastAt
+}
+
+func (c *Check) cached(maxAge time.Duration) *CheckResult {
+	result, at := c.state.snapshot()
+	if result == nil || time.Since(at) >= maxAge {
+		return nil
+	}
+	result.Cached = true
+	return result
+}
+
+// evaluate returns the result for a probe, running the check only when no usable cached result exists.
+func (c *Check) evaluate(ctx context.Context) *CheckResult {
+	if c.Interval > 0 {
+		result, at := c.state.snapshot()
+		if result == nil {
+			return c.runShared(c.Interval)
+		}
+		result.Cached = true
+		if age := time.Since(at); c.MaxStale > 0 && age > c.MaxStale {
+			result.Status = StatusDown
+			result.Error = "stale, last checked " + age.Round(time.Millisecond).String() + " ago"
+		}
+		return result
+	}
+	if c.CacheTTL > 0 {
+		if result := c.cached(c.CacheTTL); result != nil {
+			return result
+		}
+		return c.runShared(c.CacheTTL)
+	}
+	c.run(ctx)
+	result, _ := c.state.snapshot()
+	return result
+}
+
+// runShared runs the check on a detached context bounded by its timeout, the result outlives the probe that triggered it.
+func (c *Check) runShared(ttl time.Duration) *CheckResult {
+	c.state.runMu.Lock()
+	defer c.state.runMu.Unlock()
+	if result := c.cached(ttl); result != nil {
+		return result
+	}
+	c.run(context.Background())
+	result, _ := c.state.snapshot()
+	return result
+}
+
+func (c *Check) start() {
+	if c.Interval <= 0 {
+		return
+	}
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	if c.state.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.state.cancel = cancel
+	go func() {
+		ticker := time.NewTicker(c.Interval)
+		defer ticker.Stop()
+		for {
+			c.state.runMu.Lock()
+			c.run(ctx)
+			c.state.runMu.Unlock()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (c *Check) stop() {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	if c.state.cancel != nil {
+		c.state.cancel()
+		c.state.cancel = nil
+	}
+}
@@ -0,0 +1,115 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheTTL(t *testing.T) {
+	r := NewRegistry()
+	var runs int32
+	r.RegisterFunc("db", func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}, WithCacheTTL(time.Minute))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Run(context.Background(), ProbeReadiness)
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Fatalf("runs %d", n)
+	}
+	if result := r.Run(context.Background(), ProbeReadiness).Checks[0]; !result.Cached || result.Status != StatusUp {
+		t.Fatalf("result %+v", result)
+	}
+}
+
+func TestCacheDetachedFromRequest(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterFunc("db", func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+			return nil
+		}
+	}, WithCacheTTL(time.Minute))
+
+	//the first probe gives up early, the cached result must not carry its cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.Run(ctx, ProbeReadiness)
+	if result := r.Run(context.Background(), ProbeReadiness).Checks[0]; result.Status != StatusUp {
+		t.Fatalf("result %+v", result)
+	}
+}
+
+func TestCacheTimeout(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterFunc("db", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithCacheTTL(time.Minute), WithTimeout(20*time.Millisecond))
+
+	if result := r.Run(context.Background(), ProbeReadiness).Checks[0]; result.Error != ErrCheckTimeout.Error() {
+		t.Fatalf("result %+v", result)
+	}
+}
+
+func TestInterval(t *testing.T) {
+	r := NewRegistry()
+	var fail, runs int32
+	r.RegisterFunc("db", func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		if atomic.LoadInt32(&fail) == 1 {
+			return errors.New("down")
+		}
+		return nil
+	}, WithInterval(20*time.Millisecond), WithHistorySize(2))
+	r.Start()
+	defer r.Stop()
+
+	time.Sleep(30 * time.Millisecond)
+	before := atomic.LoadInt32(&runs)
+	if result := r.Run(context.Background(), ProbeReadiness).Checks[0]; !result.Cached || result.Status != StatusUp {
+		t.Fatalf("result %+v", result)
+	}
+	if atomic.LoadInt32(&runs) != before {
+		t.Fatal("the probe ran an interval check")
+	}
+
+	atomic.StoreInt32(&fail, 1)
+	time.Sleep(50 * time.Millisecond)
+	atomic.StoreInt32(&fail, 0)
+	time.Sleep(50 * time.Millisecond)
+	result := r.Run(context.Background(), ProbeReadiness).Checks[0]
+	if result.Status != StatusUp || len(result.History) != 2 || result.History[0].To != StatusDown || result.History[1].To != StatusUp {
+		t.Fatalf("result %+v", result)
+	}
+}
+
+func TestIntervalStale(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterFunc("db", func(ctx context.Context) error { return nil },
+		WithInterval(time.Hour), WithMaxStale(20*time.Millisecond))
+
+	//without a background run the probe runs the check once
+	if result := r.Run(context.Background(), ProbeReadiness).Checks[0]; result.Status != StatusUp {
+		t.Fatalf("result %+v", result)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if result := r.Run(context.Background(), ProbeReadiness).Checks[0]; result.Status != StatusDown {
+		t.Fatalf("result %+v", result)
+	}
+}
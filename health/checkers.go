@@ -0,0 +1,65 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"net"
+)
+
+func RedisChecker(client redis.UniversalClient) Checker {
+	if client == nil {
+		panic("client 必须设置")
+	}
+	return CheckerFunc(func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	})
+}
+
+func TCPChecker(addr string) Checker {
+	if addr == "" {
+		panic("addr 必须设置")
+	}
+	return CheckerFunc(func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	})
+}
+
+func DNSChecker(host string) Checker {
+	if host == "" {
+		panic("host 必须设置")
+	}
+	return CheckerFunc(func(ctx context.Context) error {
+		addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil {
+			return err
+		}
+		if len(addrs) == 0 {
+			return errors.New("no address for " + host)
+		}
+		return nil
+	})
+}
+
+// DiskChecker fails when the free space of the filesystem holding path drops below minFree bytes.
+func DiskChecker(path string, minFree uint64) Checker {
+	if path == "" {
+		panic("path 必须设置")
+	}
+	return CheckerFunc(func(ctx context.Context) error {
+		free, err := diskFree(path)
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("disk free %d bytes below %d", free, minFree)
+		}
+		return nil
+	})
+}
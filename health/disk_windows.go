@@ -0,0 +1,7 @@
+package health
+
+import "errors"
+
+func diskFree(path string) (uint64, error) {
+	return 0, errors.New("disk check is not supported on windows")
+}
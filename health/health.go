@@ -3,17 +3,25 @@ package health
 import (
 	localTime "github.com/go-tron/local-time"
 	"github.com/kataras/iris/v12"
+	"net/http"
 )
 
 func defaultConfig() *Config {
 	return &Config{
-		Path: "/health/check",
+		Path:        "/health/check",
+		LivePath:    "/livez",
+		ReadyPath:   "/readyz",
+		StartupPath: "/startupz",
 	}
 }
 
 type Config struct {
-	Path     string
-	Handlers []iris.Handler
+	Path        string
+	Handlers    []iris.Handler
+	LivePath    string
+	ReadyPath   string
+	StartupPath string
+	Registry    *Registry
 }
 
 type Option func(*Config)
@@ -30,15 +38,71 @@ func WithHandlers(handlers ...iris.Handler) Option {
 	}
 }
 
-func New(app *iris.Application, opts ...Option) {
+// WithLivePath empty disables the probe, same for the ready and startup paths.
+func WithLivePath(path string) Option {
+	return func(opts *Config) {
+		opts.LivePath = path
+	}
+}
+func WithReadyPath(path string) Option {
+	return func(opts *Config) {
+		opts.ReadyPath = path
+	}
+}
+func WithStartupPath(path string) Option {
+	return func(opts *Config) {
+		opts.StartupPath = path
+	}
+}
+func WithRegistry(registry *Registry) Option {
+	return func(opts *Config) {
+		opts.Registry = registry
+	}
+}
+
+func reportHandler(run func(ctx iris.Context) *Report) iris.Handler {
+	return func(ctx iris.Context) {
+		report := run(ctx)
+		ctx.Header("Cache-Control", "no-store")
+		if report.Status == StatusDown {
+			ctx.StatusCode(http.StatusServiceUnavailable)
+		}
+		ctx.JSON(report)
+	}
+}
+
+// New registers the legacy POST check and the GET probes, it returns the registry so that checks can be added later.
+func New(app *iris.Application, opts ...Option) *Registry {
 	config := defaultConfig()
 	for _, apply := range opts {
 		apply(config)
 	}
+	if config.Registry == nil {
+		config.Registry = NewRegistry()
+	}
+	registry := config.Registry
+	registry.Start()
 
 	config.Handlers = append(config.Handlers, func(ctx iris.Context) {
 		ctx.Text("check at:" + localTime.Now().String())
 	})
 
 	app.Post(config.Path, config.Handlers...)
+
+	if config.LivePath != "" {
+		app.Get(config.LivePath, reportHandler(func(ctx iris.Context) *Report {
+			return registry.Run(ctx.Request().Context(), ProbeLiveness)
+		}))
+	}
+	if config.ReadyPath != "" {
+		app.Get(config.ReadyPath, reportHandler(func(ctx iris.Context) *Report {
+			return registry.Run(ctx.Request().Context(), ProbeReadiness)
+		}))
+	}
+	if config.StartupPath != "" {
+		app.Get(config.StartupPath, reportHandler(func(ctx iris.Context) *Report {
+			return registry.Startup(ctx.Request().Context())
+		}))
+	}
+	return registry
 }
@@ -0,0 +1,30 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"testing"
+)
+
+func TestProbes(t *testing.T) {
+	app := iris.New()
+	registry := New(app)
+	defer registry.Stop()
+	registry.RegisterFunc("db", func(ctx context.Context) error { return errors.New("down") })
+
+	e := httptest.New(t, app)
+	e.POST("/health/check").Expect().Status(iris.StatusOK).Body().HasPrefix("check at:")
+	e.GET("/livez").Expect().Status(iris.StatusOK).JSON().Object().Value("status").IsEqual(StatusUp)
+	ready := e.GET("/readyz").Expect().Status(iris.StatusServiceUnavailable)
+	ready.Header("Cache-Control").IsEqual("no-store")
+	ready.JSON().Object().Value("probe").IsEqual("readiness")
+	e.GET("/startupz").Expect().Status(iris.StatusServiceUnavailable)
+}
+
+func TestDisabledProbe(t *testing.T) {
+	app := iris.New()
+	New(app, WithLivePath(""), WithRegistry(NewRegistry())).Stop()
+	httptest.New(t, app).GET("/livez").Expect().Status(iris.StatusNotFound)
+}
@@ -0,0 +1,335 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Probe uint8
+
+const (
+	ProbeLiveness Probe = 1 << iota
+	ProbeReadiness
+	ProbeStartup
+)
+
+func (p Probe) String() string {
+	switch p {
+	case ProbeLiveness:
+		return "liveness"
+	case ProbeReadiness:
+		return "readiness"
+	case ProbeStartup:
+		return "startup"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded"
+)
+
+var ErrCheckTimeout = errors.New("check timeout")
+
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+type CheckOption func(*Check)
+
+func WithTimeout(val time.Duration) CheckOption {
+	return func(opts *Check) {
+		opts.Timeout = val
+	}
+}
+
+// WithCritical false only degrades the report, the probe keeps returning 200.
+func WithCritical(val bool) CheckOption {
+	return func(opts *Check) {
+		opts.Critical = val
+	}
+}
+
+// WithProbes selects the probes running the check, readiness and startup by default.
+func WithProbes(probes ...Probe) CheckOption {
+	return func(opts *Check) {
+		opts.Probes = 0
+		for _, probe := range probes {
+			opts.Probes |= probe
+		}
+	}
+}
+
+// WithInterval runs the check in the background, probes read its last result.
+func WithInterval(val time.Duration) CheckOption {
+	return func(opts *Check) {
+		opts.Interval = val
+	}
+}
+
+// WithCacheTTL reuses the last result for the given duration, concurrent probes share a single run.
+func WithCacheTTL(val time.Duration) CheckOption {
+	return func(opts *Check) {
+		opts.CacheTTL = val
+	}
+}
+
+// WithMaxStale sets the age after which a cached result is reported as down, defaults to 3 intervals.
+func WithMaxStale(val time.Duration) CheckOption {
+	return func(opts *Check) {
+		opts.MaxStale = val
+	}
+}
+func WithHistorySize(val int) CheckOption {
+	return func(opts *Check) {
+		opts.HistorySize = val
+	}
+}
+
+type Check struct {
+	Name        string
+	Checker     Checker
+	Timeout     time.Duration
+	Critical    bool
+	Probes      Probe
+	Interval    time.Duration
+	CacheTTL    time.Duration
+	MaxStale    time.Duration
+	HistorySize int
+	state       checkState
+}
+
+type CheckResult struct {
+	Name      string        `json:"name"`
+	Status    string        `json:"status"`
+	Critical  bool          `json:"critical"`
+	Latency   string        `json:"latency"`
+	Error     string        `json:"error,omitempty"`
+	CheckedAt string        `json:"checkedAt"`
+	Cached    bool          `json:"cached,omitempty"`
+	History   []*Transition `json:"history,omitempty"`
+}
+
+type Report struct {
+	Probe     string         `json:"probe"`
+	Status    string         `json:"status"`
+	Checks    []*CheckResult `json:"checks"`
+	Timestamp string         `json:"timestamp"`
+}
+
+// Registry holds the named checks shared by the probes, middlewares can register their own checks into it.
+type Registry struct {
+	DefaultTimeout time.Duration
+	mu             sync.RWMutex
+	checks         []*Check
+	started        bool
+	running        bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		DefaultTimeout: 3 * time.Second,
+	}
+}
+
+func (r *Registry) Register(name string, checker Checker, opts ...CheckOption) {
+	if name == "" {
+		panic("name 必须设置")
+	}
+	if checker == nil {
+		panic("checker 必须设置")
+	}
+	check := &Check{
+		Name:        name,
+		Checker:     checker,
+		Timeout:     r.DefaultTimeout,
+		Critical:    true,
+		Probes:      ProbeReadiness | ProbeStartup,
+		HistorySize: 10,
+	}
+	for _, apply := range opts {
+		apply(check)
+	}
+	if check.Interval > 0 && check.MaxStale == 0 {
+		check.MaxStale = 3 * check.Interval
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running {
+		check.start()
+	}
+	for i, c := range r.checks {
+		if c.Name == name {
+			c.stop()
+			r.checks[i] = check
+			return
+		}
+	}
+	r.checks = append(r.checks, check)
+}
+
+func (r *Registry) RegisterFunc(name string, fn func(ctx context.Context) error, opts ...CheckOption) {
+	r.Register(name, CheckerFunc(fn), opts...)
+}
+
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, c := range r.checks {
+		if c.Name == name {
+			c.stop()
+			r.checks = append(r.checks[:i], r.checks[i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *Registry) Checks(probe Probe) []*Check {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var checks []*Check
+	for _, c := range r.checks {
+		if c.Probes&probe != 0 {
+			checks = append(checks, c)
+		}
+	}
+	return checks
+}
+
+func runCheck(ctx context.Context, check *Check) (err error) {
+	if check.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, check.Timeout)
+		defer cancel()
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+		done <- check.Checker.Check(ctx)
+	}()
+	select {
+	case err = <-done:
+		return err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return ErrCheckTimeout
+		}
+		return ctx.Err()
+	}
+}
+
+func (c *Check) run(ctx context.Context) *CheckResult {
+	start := time.Now()
+	err := runCheck(ctx, c)
+	result := &CheckResult{
+		Name:      c.Name,
+		Status:    StatusUp,
+		Critical:  c.Critical,
+		Latency:   time.Since(start).Round(time.Microsecond).String(),
+		CheckedAt: start.Format(time.RFC3339Nano),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	c.state.record(result, start, c.HistorySize)
+	return result
+}
+
+// Run executes the checks of the probe in parallel.
+func (r *Registry) Run(ctx context.Context, probe Probe) *Report {
+	checks := r.Checks(probe)
+	results := make([]*CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check *Check) {
+			defer wg.Done()
+			results[i] = check.evaluate(ctx)
+		}(i, check)
+	}
+	wg.Wait()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	report := &Report{
+		Probe:     probe.String(),
+		Status:    StatusUp,
+		Checks:    results,
+		Timestamp: time.Now().Format(time.RFC3339Nano),
+	}
+	for _, result := range results {
+		if result.Status == StatusUp {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusDown
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+// Start launches the background checks, including the ones registered later.
+func (r *Registry) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running {
+		return
+	}
+	r.running = true
+	for _, c := range r.checks {
+		c.start()
+	}
+}
+
+func (r *Registry) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.running = false
+	for _, c := range r.checks {
+		c.stop()
+	}
+}
+
+// Startup runs the startup checks until they pass once, later calls report up without running them.
+func (r *Registry) Startup(ctx context.Context) *Report {
+	r.mu.RLock()
+	started := r.started
+	r.mu.RUnlock()
+	if started {
+		return &Report{
+			Probe:     ProbeStartup.String(),
+			Status:    StatusUp,
+			Checks:    []*CheckResult{},
+			Timestamp: time.Now().Format(time.RFC3339Nano),
+		}
+	}
+	report := r.Run(ctx, ProbeStartup)
+	if report.Status != StatusDown {
+		r.mu.Lock()
+		r.started = true
+		r.mu.Unlock()
+	}
+	return report
+}
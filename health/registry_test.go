@@ -0,0 +1,107 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func statusOf(report *Report, name string) *CheckResult {
+	for _, result := range report.Checks {
+		if result.Name == name {
+			return result
+		}
+	}
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterFunc("db", func(ctx context.Context) error { return nil })
+	r.RegisterFunc("cache", func(ctx context.Context) error { return errors.New("refused") }, WithCritical(false))
+	r.RegisterFunc("live", func(ctx context.Context) error { return nil }, WithProbes(ProbeLiveness))
+
+	report := r.Run(context.Background(), ProbeReadiness)
+	if report.Status != StatusDegraded || len(report.Checks) != 2 {
+		t.Fatalf("report %+v", report)
+	}
+	if report.Checks[0].Name != "cache" || report.Checks[0].Error != "refused" {
+		t.Fatalf("checks %+v", report.Checks[0])
+	}
+
+	r.RegisterFunc("db", func(ctx context.Context) error { return errors.New("down") })
+	if report := r.Run(context.Background(), ProbeReadiness); report.Status != StatusDown {
+		t.Fatalf("report %+v", report)
+	}
+	if report := r.Run(context.Background(), ProbeLiveness); report.Status != StatusUp || len(report.Checks) != 1 {
+		t.Fatalf("report %+v", report)
+	}
+
+	r.Unregister("db")
+	if report := r.Run(context.Background(), ProbeReadiness); statusOf(report, "db") != nil {
+		t.Fatalf("report %+v", report)
+	}
+}
+
+func TestRunParallel(t *testing.T) {
+	r := NewRegistry()
+	for _, name := range []string{"a", "b", "c"} {
+		r.RegisterFunc(name, func(ctx context.Context) error {
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		})
+	}
+	start := time.Now()
+	r.Run(context.Background(), ProbeReadiness)
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Fatalf("the checks ran one after the other, %s", elapsed)
+	}
+}
+
+func TestTimeoutAndPanic(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterFunc("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}, WithTimeout(20*time.Millisecond))
+	r.RegisterFunc("panic", func(ctx context.Context) error {
+		panic("boom")
+	})
+
+	report := r.Run(context.Background(), ProbeReadiness)
+	if result := statusOf(report, "slow"); result.Status != StatusDown || result.Error != ErrCheckTimeout.Error() {
+		t.Fatalf("result %+v", result)
+	}
+	if result := statusOf(report, "panic"); result.Status != StatusDown || result.Error != "panic: boom" {
+		t.Fatalf("result %+v", result)
+	}
+}
+
+func TestStartup(t *testing.T) {
+	r := NewRegistry()
+	var ready, runs int32
+	r.RegisterFunc("warmup", func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		if atomic.LoadInt32(&ready) == 0 {
+			return errors.New("warming up")
+		}
+		return nil
+	}, WithProbes(ProbeStartup))
+
+	if report := r.Startup(context.Background()); report.Status != StatusDown {
+		t.Fatalf("report %+v", report)
+	}
+	atomic.StoreInt32(&ready, 1)
+	if report := r.Startup(context.Background()); report.Status != StatusUp {
+		t.Fatalf("report %+v", report)
+	}
+	atomic.StoreInt32(&ready, 0)
+	if report := r.Startup(context.Background()); report.Status != StatusUp || len(report.Checks) != 0 {
+		t.Fatalf("report %+v", report)
+	}
+	if n := atomic.LoadInt32(&runs); n != 2 {
+		t.Fatalf("runs %d", n)
+	}
+}
@@ -0,0 +1,262 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/health"
+	"github.com/kataras/iris/v12"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrDraining        = errors.New("draining")
+	ErrInFlightTimeout = errors.New("in-flight requests timeout")
+)
+
+type Option func(*Config)
+
+func defaultConfig() *Config {
+	return &Config{
+		DrainPeriod:     5 * time.Second,
+		ShutdownTimeout: 30 * time.Second,
+		Signals:         []os.Signal{syscall.SIGTERM, syscall.SIGINT},
+		CheckName:       "lifecycle",
+	}
+}
+
+// WithDrainPeriod sets how long readiness fails before the server stops accepting, so load balancers can deregister the pod.
+func WithDrainPeriod(val time.Duration) Option {
+	return func(opts *Config) {
+		opts.DrainPeriod = val
+	}
+}
+
+// WithShutdownTimeout bounds the wait for in-flight requests and the flushers.
+func WithShutdownTimeout(val time.Duration) Option {
+	return func(opts *Config) {
+		opts.ShutdownTimeout = val
+	}
+}
+func WithSignals(val ...os.Signal) Option {
+	return func(opts *Config) {
+		opts.Signals = val
+	}
+}
+func WithRegistry(val *health.Registry) Option {
+	return func(opts *Config) {
+		opts.Registry = val
+	}
+}
+func WithCheckName(val string) Option {
+	return func(opts *Config) {
+		opts.CheckName = val
+	}
+}
+func WithFlusher(name string, fn func(ctx context.Context) error) Option {
+	return func(opts *Config) {
+		opts.Flushers = append(opts.Flushers, Flusher{name, fn})
+	}
+}
+func WithLogger(val func(msg string, err error)) Option {
+	return func(opts *Config) {
+		opts.Logger = val
+	}
+}
+
+// Flusher is run in registration order once the in-flight requests are done, e.g. the requestLogger and the tracer.
+type Flusher struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+type Config struct {
+	DrainPeriod     time.Duration
+	ShutdownTimeout time.Duration
+	Signals         []os.Signal
+	Registry        *health.Registry
+	CheckName       string
+	Flushers        []Flusher
+	Logger          func(msg string, err error)
+}
+
+type Lifecycle struct {
+	*Config
+	app      *iris.Application
+	draining atomic.Bool
+	inFlight sync.WaitGroup
+	count    atomic.Int64
+	//trackMu orders inFlight.Add before the final Wait, requests arriving after it are not tracked
+	trackMu sync.RWMutex
+	closed  bool
+	mu      sync.Mutex
+	once    sync.Once
+	done    chan struct{}
+	err     error
+}
+
+func New(app *iris.Application, opts ...Option) *Lifecycle {
+	if app == nil {
+		panic("app 必须设置")
+	}
+	config := defaultConfig()
+	for _, apply := range opts {
+		apply(config)
+	}
+
+	l := &Lifecycle{
+		Config: config,
+		app:    app,
+		done:   make(chan struct{}),
+	}
+	if config.Registry != nil {
+		config.Registry.RegisterFunc(config.CheckName, func(ctx context.Context) error {
+			if l.Draining() {
+				return ErrDraining
+			}
+			return nil
+		}, health.WithProbes(health.ProbeReadiness), health.WithTimeout(0))
+	}
+	return l
+}
+
+func (l *Lifecycle) OnShutdown(name string, fn func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Flushers = append(l.Flushers, Flusher{name, fn})
+}
+
+func (l *Lifecycle) Draining() bool {
+	return l.draining.Load()
+}
+
+func (l *Lifecycle) InFlight() int64 {
+	return l.count.Load()
+}
+
+// Context tracks the in-flight requests, register it first so that it wraps the whole chain.
+func (l *Lifecycle) Context(ctx *baseContext.Context) {
+	if l.track() {
+		l.count.Add(1)
+		defer func() {
+			l.count.Add(-1)
+			l.inFlight.Done()
+		}()
+	}
+	if l.Draining() {
+		//ask keep-alive clients to reconnect to another instance
+		ctx.Header("Connection", "close")
+	}
+	ctx.Next()
+}
+
+func (l *Lifecycle) track() bool {
+	l.trackMu.RLock()
+	defer l.trackMu.RUnlock()
+	if l.closed {
+		return false
+	}
+	l.inFlight.Add(1)
+	return true
+}
+
+func (l *Lifecycle) Handler() iris.Handler {
+	return baseContext.Handler(l.Context)
+}
+
+func (l *Lifecycle) log(msg string, err error) {
+	if l.Logger != nil {
+		l.Logger(msg, err)
+	}
+}
+
+// Run starts the app and shuts it down gracefully on the configured signals, it returns once the shutdown has finished.
+func (l *Lifecycle) Run(runner iris.Runner, configurators ...iris.Configurator) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, l.Signals...)
+	defer signal.Stop(signals)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			l.log("shutdown on "+sig.String(), nil)
+			l.Shutdown()
+		case <-stop:
+		}
+	}()
+
+	configurators = append(configurators, iris.WithoutInterruptHandler, iris.WithoutServerError(iris.ErrServerClosed))
+	if err := l.app.Run(runner, configurators...); err != nil {
+		return err
+	}
+	//the server was closed by Shutdown, wait for the flushers
+	if l.Draining() {
+		<-l.done
+		return l.err
+	}
+	return nil
+}
+
+// Shutdown flips readiness, waits the drain period, closes the server, waits for the in-flight requests and runs the flushers.
+func (l *Lifecycle) Shutdown() error {
+	l.once.Do(func() {
+		defer close(l.done)
+		l.draining.Store(true)
+		if l.DrainPeriod > 0 {
+			time.Sleep(l.DrainPeriod)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), l.ShutdownTimeout)
+		defer cancel()
+
+		if err := l.app.Shutdown(ctx); err != nil {
+			l.log("server shutdown", err)
+			l.err = err
+		}
+		if err := l.wait(ctx); err != nil {
+			l.log("wait in-flight requests", err)
+			l.err = err
+		}
+
+		l.mu.Lock()
+		flushers := append([]Flusher(nil), l.Flushers...)
+		l.mu.Unlock()
+		for _, f := range flushers {
+			if err := f.Fn(ctx); err != nil {
+				l.log("flush "+f.Name, err)
+				if l.err == nil {
+					l.err = err
+				}
+			}
+		}
+		if l.Registry != nil {
+			l.Registry.Stop()
+		}
+	})
+	<-l.done
+	return l.err
+}
+
+func (l *Lifecycle) wait(ctx context.Context) error {
+	l.trackMu.Lock()
+	l.closed = true
+	l.trackMu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		l.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ErrInFlightTimeout
+	}
+}
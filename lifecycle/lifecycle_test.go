@@ -0,0 +1,190 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/health"
+	"github.com/go-tron/iris/response"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func init() {
+	baseContext.New("test", nil, baseContext.WithResponse(response.New()))
+}
+
+func start(t *testing.T, l *Lifecycle) (string, chan error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ran := make(chan error, 1)
+	go func() {
+		ran <- l.Run(iris.Listener(ln), iris.WithoutStartupLog)
+	}()
+	url := "http://" + ln.Addr().String()
+	for i := 0; i < 100; i++ {
+		if resp, err := http.Get(url + "/fast"); err == nil {
+			resp.Body.Close()
+			return url, ran
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("the server did not start")
+	return "", nil
+}
+
+func newApp(started chan struct{}, sleep time.Duration) *iris.Application {
+	app := iris.New()
+	app.Get("/fast", func(ctx iris.Context) {
+		ctx.WriteString("fast")
+	})
+	app.Get("/slow", func(ctx iris.Context) {
+		close(started)
+		time.Sleep(sleep)
+		ctx.WriteString("slow")
+	})
+	return app
+}
+
+func TestShutdownWaitsInFlight(t *testing.T) {
+	started := make(chan struct{})
+	app := newApp(started, 100*time.Millisecond)
+	var mu sync.Mutex
+	var order []string
+	flusher := func(name string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+	l := New(app, WithDrainPeriod(0), WithFlusher("logger", flusher("logger")))
+	l.OnShutdown("tracer", flusher("tracer"))
+	app.UseRouter(l.Handler())
+	url, ran := start(t, l)
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+	if l.InFlight() != 1 {
+		t.Fatalf("in-flight %d", l.InFlight())
+	}
+	if err := l.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if b := <-body; b != "slow" {
+		t.Fatalf("body %s", b)
+	}
+	if err := <-ran; err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 2 || order[0] != "logger" || order[1] != "tracer" {
+		t.Fatalf("flushers %v", order)
+	}
+}
+
+func TestDraining(t *testing.T) {
+	app := newApp(make(chan struct{}), 0)
+	registry := health.NewRegistry()
+	l := New(app, WithDrainPeriod(100*time.Millisecond), WithRegistry(registry))
+	app.UseRouter(l.Handler())
+	url, ran := start(t, l)
+
+	if report := registry.Run(context.Background(), health.ProbeReadiness); report.Status != health.StatusUp {
+		t.Fatalf("report %+v", report)
+	}
+	go l.Shutdown()
+	time.Sleep(20 * time.Millisecond)
+	if report := registry.Run(context.Background(), health.ProbeReadiness); report.Status != health.StatusDown {
+		t.Fatalf("report %+v", report)
+	}
+	//the server keeps serving during the drain period
+	resp, err := http.Get(url + "/fast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !resp.Close {
+		t.Fatalf("status %d, close %v", resp.StatusCode, resp.Close)
+	}
+	if err := <-ran; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShutdownInFlightTimeout(t *testing.T) {
+	started := make(chan struct{})
+	app := newApp(started, 300*time.Millisecond)
+	l := New(app, WithDrainPeriod(0), WithShutdownTimeout(50*time.Millisecond))
+	app.UseRouter(l.Handler())
+	url, ran := start(t, l)
+
+	go http.Get(url + "/slow")
+	<-started
+	if err := l.Shutdown(); !errors.Is(err, ErrInFlightTimeout) {
+		t.Fatalf("err %v", err)
+	}
+	if err := <-ran; !errors.Is(err, ErrInFlightTimeout) {
+		t.Fatalf("err %v", err)
+	}
+}
+
+func TestRunReturns(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	//the address is taken
+	l := New(iris.New())
+	if err := l.Run(iris.Addr(ln.Addr().String()), iris.WithoutStartupLog); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	//the server closed without Shutdown
+	app := newApp(make(chan struct{}), 0)
+	l = New(app)
+	_, ran := start(t, l)
+	app.Shutdown(context.Background())
+	select {
+	case err := <-ran:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return")
+	}
+}
+
+func TestRequestsAfterShutdown(t *testing.T) {
+	app := newApp(make(chan struct{}), 0)
+	l := New(app, WithDrainPeriod(0))
+	app.UseRouter(l.Handler())
+	e := httptest.New(t, app)
+	if err := l.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	//late requests are served without being tracked
+	e.GET("/fast").Expect().Status(iris.StatusOK).Header("Connection").IsEqual("close")
+	if l.InFlight() != 0 {
+		t.Fatalf("in-flight %d", l.InFlight())
+	}
+}
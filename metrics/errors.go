@@ -0,0 +1,342 @@
+package metrics
+
+import (
+	"github.com/go-tron/iris/baseContext"
+	"github.com/kataras/iris/v12"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+type ErrorStatsOption func(*ErrorStatsConfig)
+
+func defaultErrorStatsConfig() *ErrorStatsConfig {
+	return &ErrorStatsConfig{
+		Windows:    []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour},
+		Resolution: 10 * time.Second,
+		TopN:       20,
+		Path:       "/admin/errors",
+		AllowIPs:   []string{"127.0.0.0/8", "::1/128"},
+	}
+}
+
+func WithWindows(val ...time.Duration) ErrorStatsOption {
+	return func(opts *ErrorStatsConfig) {
+		opts.Windows = val
+	}
+}
+
+// WithResolution is the size of the buckets the windows slide by.
+func WithResolution(val time.Duration) ErrorStatsOption {
+	return func(opts *ErrorStatsConfig) {
+		opts.Resolution = val
+	}
+}
+func WithTopN(val int) ErrorStatsOption {
+	return func(opts *ErrorStatsConfig) {
+		opts.TopN = val
+	}
+}
+func WithStatsPath(val string) ErrorStatsOption {
+	return func(opts *ErrorStatsConfig) {
+		opts.Path = val
+	}
+}
+func WithStatsAllowIPs(val ...string) ErrorStatsOption {
+	return func(opts *ErrorStatsConfig) {
+		opts.AllowIPs = val
+	}
+}
+
+// WithThreshold alerts once count responses with the code happened within the window, at most once per window.
+func WithThreshold(code string, count int64, window time.Duration) ErrorStatsOption {
+	return func(opts *ErrorStatsConfig) {
+		opts.Thresholds = append(opts.Thresholds, Threshold{code, count, window})
+	}
+}
+
+// WithAlertHook hooks run outside the request goroutine.
+func WithAlertHook(val ...AlertHook) ErrorStatsOption {
+	return func(opts *ErrorStatsConfig) {
+		opts.AlertHooks = append(opts.AlertHooks, val...)
+	}
+}
+
+type ErrorStatsConfig struct {
+	Windows    []time.Duration
+	Resolution time.Duration
+	TopN       int
+	Path       string
+	AllowIPs   []string
+	Thresholds []Threshold
+	AlertHooks []AlertHook
+}
+
+type Threshold struct {
+	Code   string        `json:"code"`
+	Count  int64         `json:"count"`
+	Window time.Duration `json:"window"`
+}
+
+// Alert counts the code over all the routes, Window reports the routes it came from.
+type Alert struct {
+	Threshold
+	Actual int64     `json:"actual"`
+	System bool      `json:"system"`
+	Time   time.Time `json:"time"`
+}
+
+type AlertHook func(alert *Alert)
+
+type RouteCount struct {
+	Route string `json:"route"`
+	Count int64  `json:"count"`
+}
+
+type CodeCount struct {
+	Code   string       `json:"code"`
+	System bool         `json:"system"`
+	Count  int64        `json:"count"`
+	Routes []RouteCount `json:"routes"`
+}
+
+type WindowReport struct {
+	Window   string      `json:"window"`
+	Total    int64       `json:"total"`
+	System   int64       `json:"system"`
+	Business int64       `json:"business"`
+	Top      []CodeCount `json:"top"`
+}
+
+type errorKey struct {
+	code   string
+	system bool
+	route  string
+}
+
+type errorBucket struct {
+	index  int64
+	counts map[errorKey]int64
+}
+
+type threshold struct {
+	Threshold
+	firedAt time.Time
+}
+
+// ErrorStats tallies the error codes rendered by Context.Error over sliding windows.
+type ErrorStats struct {
+	*ErrorStatsConfig
+	mu         sync.Mutex
+	buckets    []errorBucket
+	thresholds map[string][]*threshold
+	allowed    []*net.IPNet
+}
+
+func NewErrorStats(opts ...ErrorStatsOption) *ErrorStats {
+	config := defaultErrorStatsConfig()
+	for _, apply := range opts {
+		apply(config)
+	}
+	if config.Resolution <= 0 {
+		panic("Resolution 必须设置")
+	}
+	if len(config.Windows) == 0 {
+		panic("Windows 必须设置")
+	}
+
+	maxWindow := config.Windows[0]
+	for _, window := range config.Windows {
+		if window < config.Resolution {
+			panic("window 不支持:" + window.String())
+		}
+		if window > maxWindow {
+			maxWindow = window
+		}
+	}
+	thresholds := make(map[string][]*threshold)
+	for _, t := range config.Thresholds {
+		if t.Code == "" || t.Count <= 0 || t.Window < config.Resolution {
+			panic("threshold 不支持:" + t.Code)
+		}
+		if t.Window > maxWindow {
+			maxWindow = t.Window
+		}
+		thresholds[t.Code] = append(thresholds[t.Code], &threshold{Threshold: t})
+	}
+
+	return &ErrorStats{
+		ErrorStatsConfig: config,
+		buckets:          make([]errorBucket, int((maxWindow+config.Resolution-1)/config.Resolution)),
+		thresholds:       thresholds,
+		allowed:          parseAllowIPs(config.AllowIPs),
+	}
+}
+
+func (s *ErrorStats) bucketIndex(t time.Time) int64 {
+	return t.UnixNano() / int64(s.Resolution)
+}
+
+// count sums the buckets of the window, mu must be held.
+func (s *ErrorStats) count(now int64, window time.Duration, visit func(key errorKey, count int64)) {
+	n := int64((window + s.Resolution - 1) / s.Resolution)
+	for i := int64(0); i < n; i++ {
+		b := &s.buckets[(now-i)%int64(len(s.buckets))]
+		if b.index != now-i {
+			continue
+		}
+		for key, count := range b.counts {
+			visit(key, count)
+		}
+	}
+}
+
+func (s *ErrorStats) Record(code string, system bool, route string) {
+	now := time.Now()
+	index := s.bucketIndex(now)
+	key := errorKey{code, system, route}
+
+	s.mu.Lock()
+	b := &s.buckets[index%int64(len(s.buckets))]
+	if b.index != index || b.counts == nil {
+		b.index = index
+		b.counts = make(map[errorKey]int64)
+	}
+	b.counts[key]++
+
+	var alerts []*Alert
+	for _, t := range s.thresholds[code] {
+		if !t.firedAt.IsZero() && now.Sub(t.firedAt) < t.Window {
+			continue
+		}
+		var actual int64
+		s.count(index, t.Window, func(k errorKey, count int64) {
+			if k.code == code {
+				actual += count
+			}
+		})
+		if actual >= t.Count {
+			t.firedAt = now
+			alerts = append(alerts, &Alert{Threshold: t.Threshold, Actual: actual, System: system, Time: now})
+		}
+	}
+	s.mu.Unlock()
+
+	for _, alert := range alerts {
+		go s.alert(alert)
+	}
+}
+
+func (s *ErrorStats) alert(alert *Alert) {
+	for _, hook := range s.AlertHooks {
+		baseContext.CallSafely(func() {
+			hook(alert)
+		})
+	}
+}
+
+// Window reports the top codes of the window, topN <= 0 means all of them.
+func (s *ErrorStats) Window(window time.Duration, topN int) *WindowReport {
+	type codeKey struct {
+		code   string
+		system bool
+	}
+	codes := make(map[codeKey]*CodeCount)
+	routes := make(map[codeKey]map[string]int64)
+	report := &WindowReport{Window: window.String(), Top: []CodeCount{}}
+
+	s.mu.Lock()
+	s.count(s.bucketIndex(time.Now()), window, func(key errorKey, count int64) {
+		ck := codeKey{key.code, key.system}
+		c, ok := codes[ck]
+		if !ok {
+			c = &CodeCount{Code: key.code, System: key.system}
+			codes[ck] = c
+			routes[ck] = make(map[string]int64)
+		}
+		c.Count += count
+		routes[ck][key.route] += count
+		report.Total += count
+		if key.system {
+			report.System += count
+		} else {
+			report.Business += count
+		}
+	})
+	s.mu.Unlock()
+
+	for ck, c := range codes {
+		for route, count := range routes[ck] {
+			c.Routes = append(c.Routes, RouteCount{route, count})
+		}
+		sort.Slice(c.Routes, func(i, j int) bool {
+			if c.Routes[i].Count != c.Routes[j].Count {
+				return c.Routes[i].Count > c.Routes[j].Count
+			}
+			return c.Routes[i].Route < c.Routes[j].Route
+		})
+		report.Top = append(report.Top, *c)
+	}
+	sort.Slice(report.Top, func(i, j int) bool {
+		if report.Top[i].Count != report.Top[j].Count {
+			return report.Top[i].Count > report.Top[j].Count
+		}
+		return report.Top[i].Code < report.Top[j].Code
+	})
+	if topN > 0 && len(report.Top) > topN {
+		report.Top = report.Top[:topN]
+	}
+	return report
+}
+
+func (s *ErrorStats) Report(topN int) []*WindowReport {
+	reports := make([]*WindowReport, 0, len(s.Windows))
+	for _, window := range s.Windows {
+		reports = append(reports, s.Window(window, topN))
+	}
+	return reports
+}
+
+// Context must run before recover for the panics to be counted.
+func (s *ErrorStats) Context(ctx *baseContext.Context) {
+	defer func() {
+		if e := ctx.GetResponseError(); e != nil {
+			s.Record(e.Code, e.System, route(ctx))
+		}
+	}()
+	ctx.Next()
+}
+
+func (s *ErrorStats) Handler() iris.Handler {
+	return baseContext.Handler(s.Context)
+}
+
+// ExposeHandler serves the report as JSON, ?window=5m restricts it to a window and ?top=n overrides TopN.
+func (s *ErrorStats) ExposeHandler() iris.Handler {
+	return baseContext.Handler(func(ctx *baseContext.Context) {
+		if ip := ctx.RemoteAddr(); !allowedIP(s.allowed, ip) {
+			ctx.StatusCode(iris.StatusForbidden)
+			ctx.Error(ErrorForbidden(ip))
+			return
+		}
+		topN := ctx.URLParamIntDefault("top", s.TopN)
+		ctx.Header("Cache-Control", "no-store")
+		if window := ctx.URLParam("window"); window != "" {
+			d, err := time.ParseDuration(window)
+			if err != nil || d < s.Resolution || d > time.Duration(len(s.buckets))*s.Resolution {
+				ctx.StatusCode(iris.StatusBadRequest)
+				ctx.Error(baseContext.ErrorReadParams("window"))
+				return
+			}
+			ctx.JSON(s.Window(d, topN))
+			return
+		}
+		ctx.JSON(s.Report(topN))
+	})
+}
+
+// Expose registers the admin path on the app.
+func (s *ErrorStats) Expose(app *iris.Application) {
+	app.Get(s.Path, s.ExposeHandler())
+}
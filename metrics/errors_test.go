@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"github.com/go-tron/iris/baseContext"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"testing"
+	"time"
+)
+
+func TestErrorStatsWindow(t *testing.T) {
+	s := NewErrorStats(WithWindows(time.Minute), WithResolution(time.Second))
+	s.Record("1001", false, "/a")
+	s.Record("1001", false, "/a")
+	s.Record("1001", false, "/b")
+	s.Record("100", true, "/a")
+
+	report := s.Window(time.Minute, 0)
+	if report.Total != 4 || report.System != 1 || report.Business != 3 || len(report.Top) != 2 {
+		t.Fatalf("report %+v", report)
+	}
+	top := report.Top[0]
+	if top.Code != "1001" || top.Count != 3 || len(top.Routes) != 2 || top.Routes[0] != (RouteCount{"/a", 2}) {
+		t.Fatalf("top %+v", top)
+	}
+	if report := s.Window(time.Minute, 1); len(report.Top) != 1 {
+		t.Fatalf("report %+v", report)
+	}
+	if reports := s.Report(0); len(reports) != 1 || reports[0].Window != "1m0s" {
+		t.Fatalf("reports %+v", reports)
+	}
+}
+
+func TestErrorStatsAlert(t *testing.T) {
+	alerts := make(chan *Alert, 4)
+	s := NewErrorStats(
+		WithResolution(time.Second),
+		WithThreshold("100", 3, time.Minute),
+		WithAlertHook(func(alert *Alert) { panic("hook") }, func(alert *Alert) { alerts <- alert }),
+	)
+	//the threshold counts the code over all the routes
+	s.Record("100", true, "/a")
+	s.Record("100", true, "/b")
+	s.Record("1001", false, "/a")
+	s.Record("100", true, "/c")
+	s.Record("100", true, "/c")
+
+	select {
+	case alert := <-alerts:
+		if alert.Code != "100" || alert.Actual != 3 || !alert.System {
+			t.Fatalf("alert %+v", alert)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no alert")
+	}
+	//at most once per window
+	select {
+	case alert := <-alerts:
+		t.Fatalf("alert %+v", alert)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestErrorStatsHandler(t *testing.T) {
+	s := NewErrorStats(WithStatsAllowIPs())
+	app := iris.New()
+	app.Use(s.Handler())
+	app.Get("/users/{id}", baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.Error(errorTest("fail"))
+	}))
+	app.Get("/ok", baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.Success()
+	}))
+	s.Expose(app)
+	e := httptest.New(t, app)
+	e.GET("/users/1").Expect()
+	e.GET("/users/2").Expect()
+	e.GET("/ok").Expect()
+
+	resp := e.GET("/admin/errors").WithQuery("window", "5m").Expect().Status(iris.StatusOK)
+	resp.Header("Cache-Control").IsEqual("no-store")
+	body := resp.JSON().Object()
+	body.Value("total").IsEqual(2)
+	top := body.Value("top").Array().Value(0).Object()
+	top.Value("code").IsEqual("4399")
+	top.Value("routes").Array().Value(0).Object().Value("route").IsEqual("/users/{id}")
+
+	e.GET("/admin/errors").Expect().Status(iris.StatusOK).JSON().Array().Length().IsEqual(4)
+	e.GET("/admin/errors").WithQuery("window", "1ms").Expect().Status(iris.StatusBadRequest)
+	e.GET("/admin/errors").WithQuery("window", "2h").Expect().Status(iris.StatusBadRequest)
+
+	s = NewErrorStats(WithStatsAllowIPs("10.0.0.1"))
+	app = iris.New()
+	s.Expose(app)
+	httptest.New(t, app).GET("/admin/errors").Expect().Status(iris.StatusForbidden)
+}
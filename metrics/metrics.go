@@ -0,0 +1,298 @@
+package metrics
+
+import (
+	"github.com/go-tron/base-error"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/kataras/iris/v12"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrorForbidden = baseError.Factory("4390", "metrics forbidden for {}")
+)
+
+const unmatchedRoute = "unmatched"
+
+type Option func(*Config)
+
+func defaultConfig() *Config {
+	return &Config{
+		Namespace:   "iris",
+		Path:        "/metrics",
+		AllowIPs:    []string{"127.0.0.0/8", "::1/128"},
+		Buckets:     prometheus.DefBuckets,
+		SizeBuckets: prometheus.ExponentialBuckets(100, 10, 7),
+	}
+}
+
+func WithNamespace(val string) Option {
+	return func(opts *Config) {
+		opts.Namespace = val
+	}
+}
+func WithPath(val string) Option {
+	return func(opts *Config) {
+		opts.Path = val
+	}
+}
+
+// WithAllowIPs replaces the loopback default, ips or cidrs matched against the remote address, none allows everyone.
+func WithAllowIPs(val ...string) Option {
+	return func(opts *Config) {
+		opts.AllowIPs = val
+	}
+}
+func WithBuckets(val ...float64) Option {
+	return func(opts *Config) {
+		opts.Buckets = val
+	}
+}
+func WithSizeBuckets(val ...float64) Option {
+	return func(opts *Config) {
+		opts.SizeBuckets = val
+	}
+}
+
+// WithRegistry defaults to a new registry with the go and process collectors.
+func WithRegistry(val *prometheus.Registry) Option {
+	return func(opts *Config) {
+		opts.Registry = val
+	}
+}
+
+// WithSkipPaths paths aren't measured, the exposition path never is.
+func WithSkipPaths(val ...string) Option {
+	return func(opts *Config) {
+		opts.SkipPaths = append(opts.SkipPaths, val...)
+	}
+}
+
+type Config struct {
+	Namespace   string
+	Path        string
+	AllowIPs    []string
+	Buckets     []float64
+	SizeBuckets []float64
+	Registry    *prometheus.Registry
+	SkipPaths   []string
+}
+
+type Metrics struct {
+	*Config
+	allowed          []*net.IPNet
+	skip             map[string]bool
+	requests         *prometheus.CounterVec
+	duration         *prometheus.HistogramVec
+	requestSize      *prometheus.HistogramVec
+	responseSize     *prometheus.HistogramVec
+	inFlight         prometheus.Gauge
+	limiterRejection *prometheus.CounterVec
+	authFailure      *prometheus.CounterVec
+	panics           *prometheus.CounterVec
+}
+
+func parseAllowIPs(ips []string) []*net.IPNet {
+	allowed := make([]*net.IPNet, 0, len(ips))
+	for _, ip := range ips {
+		if !strings.Contains(ip, "/") {
+			if parsed := net.ParseIP(ip); parsed != nil {
+				if parsed.To4() != nil {
+					ip += "/32"
+				} else {
+					ip += "/128"
+				}
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(ip)
+		if err != nil {
+			panic("allowIPs 不支持:" + ip)
+		}
+		allowed = append(allowed, ipNet)
+	}
+	return allowed
+}
+
+func New(opts ...Option) *Metrics {
+	config := defaultConfig()
+	for _, apply := range opts {
+		apply(config)
+	}
+	if config.Path == "" {
+		panic("Path 必须设置")
+	}
+	if config.Registry == nil {
+		config.Registry = prometheus.NewRegistry()
+		config.Registry.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		)
+	}
+
+	m := &Metrics{
+		Config:  config,
+		allowed: parseAllowIPs(config.AllowIPs),
+		skip:    map[string]bool{config.Path: true},
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Name:      "http_requests_total",
+			Help:      "Requests by method, route template, status and error code.",
+		}, []string{"method", "route", "status", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.Namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Request latency by method, route template, status and error code.",
+			Buckets:   config.Buckets,
+		}, []string{"method", "route", "status", "code"}),
+		requestSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.Namespace,
+			Name:      "http_request_size_bytes",
+			Help:      "Request body size.",
+			Buckets:   config.SizeBuckets,
+		}, []string{"method", "route"}),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.Namespace,
+			Name:      "http_response_size_bytes",
+			Help:      "Response body size.",
+			Buckets:   config.SizeBuckets,
+		}, []string{"method", "route"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: config.Namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "Requests being served.",
+		}),
+		limiterRejection: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Name:      "limiter_rejections_total",
+			Help:      "Requests rejected by a limiter.",
+		}, []string{"limiter"}),
+		authFailure: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Name:      "auth_failures_total",
+			Help:      "Failed authentications.",
+		}, []string{"reason"}),
+		panics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Name:      "panics_recovered_total",
+			Help:      "Panics recovered by route template.",
+		}, []string{"route"}),
+	}
+	for _, path := range config.SkipPaths {
+		m.skip[path] = true
+	}
+	config.Registry.MustRegister(m.requests, m.duration, m.requestSize, m.responseSize, m.inFlight,
+		m.limiterRejection, m.authFailure, m.panics)
+	return m
+}
+
+func route(ctx *baseContext.Context) string {
+	if r := ctx.GetCurrentRoute(); r != nil {
+		return r.Path()
+	}
+	return unmatchedRoute
+}
+
+// RecordEvent counts the events reported by the limiters, the authentication middlewares and recover.
+func (m *Metrics) RecordEvent(ctx *baseContext.Context, event string, reason string) {
+	switch event {
+	case baseContext.EventLimiterRejection:
+		m.limiterRejection.WithLabelValues(reason).Inc()
+	case baseContext.EventAuthFailure:
+		m.authFailure.WithLabelValues(reason).Inc()
+	case baseContext.EventPanic:
+		m.panics.WithLabelValues(route(ctx)).Inc()
+	}
+}
+
+func responseCode(ctx *baseContext.Context) string {
+	if e := ctx.GetResponseError(); e != nil {
+		return e.Code
+	}
+	return ""
+}
+
+func (m *Metrics) observe(ctx *baseContext.Context, start time.Time, status int, code string) {
+	method := ctx.Method()
+	routePath := route(ctx)
+	statusText := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, routePath, statusText, code).Inc()
+	m.duration.WithLabelValues(method, routePath, statusText, code).Observe(time.Since(start).Seconds())
+	var requestSize int64
+	if ctx.Request().ContentLength > 0 {
+		requestSize = ctx.Request().ContentLength
+	}
+	m.requestSize.WithLabelValues(method, routePath).Observe(float64(requestSize))
+	var responseSize int
+	if written := ctx.ResponseWriter().Written(); written > 0 {
+		responseSize = written
+	}
+	m.responseSize.WithLabelValues(method, routePath).Observe(float64(responseSize))
+}
+
+// Context must run before the limiters and authentication middlewares for their events to be counted.
+func (m *Metrics) Context(ctx *baseContext.Context) {
+	if m.skip[ctx.Path()] {
+		ctx.Next()
+		return
+	}
+	ctx.SetEventRecorder(m)
+	start := time.Now()
+	m.inFlight.Inc()
+	defer func() {
+		m.inFlight.Dec()
+		//with recover registered first the panic goes through here, it is measured with the code recover renders and rethrown
+		if p := recover(); p != nil {
+			m.observe(ctx, start, iris.StatusInternalServerError, ctx.PanicError(p).Code)
+			panic(p)
+		}
+		m.observe(ctx, start, ctx.GetStatusCode(), responseCode(ctx))
+	}()
+	ctx.Next()
+}
+
+func (m *Metrics) Handler() iris.Handler {
+	return baseContext.Handler(m.Context)
+}
+
+func allowedIP(allowed []*net.IPNet, ip string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range allowed {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Metrics) Allowed(ip string) bool {
+	return allowedIP(m.allowed, ip)
+}
+
+// ExposeHandler serves the registry in the Prometheus text format, the remote address is used rather than GetIP so that the allowlist can't be spoofed by a header.
+func (m *Metrics) ExposeHandler() iris.Handler {
+	h := promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{Registry: m.Registry})
+	return baseContext.Handler(func(ctx *baseContext.Context) {
+		if ip := ctx.RemoteAddr(); !m.Allowed(ip) {
+			ctx.StatusCode(iris.StatusForbidden)
+			ctx.Error(ErrorForbidden(ip))
+			return
+		}
+		h.ServeHTTP(ctx.ResponseWriter(), ctx.Request())
+	})
+}
+
+// Expose registers the exposition path on the app.
+func (m *Metrics) Expose(app *iris.Application) {
+	app.Get(m.Path, m.ExposeHandler())
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	baseError "github.com/go-tron/base-error"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/recover"
+	"github.com/go-tron/iris/response"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"testing"
+)
+
+func init() {
+	baseContext.New("test", nil, baseContext.WithResponse(response.New()))
+}
+
+var errorTest = baseError.Factory("4399", "test {}")
+
+func newApp(m *Metrics) *iris.Application {
+	app := iris.New()
+	app.Logger().SetLevel("disable")
+	app.Use(recover.New())
+	app.Use(m.Handler())
+	app.Get("/users/{id}", baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.Success()
+	}))
+	app.Get("/fail", baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.Error(errorTest("fail"))
+	}))
+	app.Get("/panic", func(ctx iris.Context) {
+		panic("boom")
+	})
+	app.Get("/business", func(ctx iris.Context) {
+		panic(errorTest("panic"))
+	})
+	app.Get("/limited", baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.RecordEvent(baseContext.EventLimiterRejection, "ip")
+		ctx.StatusCode(iris.StatusTooManyRequests)
+	}))
+	app.Get("/skip", func(ctx iris.Context) {})
+	m.Expose(app)
+	return app
+}
+
+func TestRequests(t *testing.T) {
+	m := New(WithRegistry(prometheus.NewRegistry()), WithSkipPaths("/skip"))
+	e := httptest.New(t, newApp(m))
+	e.GET("/users/1").Expect().Status(iris.StatusOK)
+	e.GET("/users/2").Expect().Status(iris.StatusOK)
+	e.GET("/fail").Expect().Status(iris.StatusOK)
+	e.GET("/missing").Expect().Status(iris.StatusNotFound)
+	e.GET("/limited").Expect().Status(iris.StatusTooManyRequests)
+	e.GET("/skip").Expect().Status(iris.StatusOK)
+
+	for _, c := range []struct {
+		labels []string
+		want   float64
+	}{
+		{[]string{"GET", "/users/{id}", "200", ""}, 2},
+		{[]string{"GET", "/fail", "200", "4399"}, 1},
+		{[]string{"GET", "/limited", "429", ""}, 1},
+		{[]string{"GET", "/skip", "200", ""}, 0},
+	} {
+		if got := testutil.ToFloat64(m.requests.WithLabelValues(c.labels...)); got != c.want {
+			t.Fatalf("%v: %v", c.labels, got)
+		}
+	}
+	if got := testutil.ToFloat64(m.limiterRejection.WithLabelValues("ip")); got != 1 {
+		t.Fatalf("rejections %v", got)
+	}
+	if got := testutil.ToFloat64(m.inFlight); got != 0 {
+		t.Fatalf("in-flight %v", got)
+	}
+}
+
+func TestPanicCode(t *testing.T) {
+	m := New(WithRegistry(prometheus.NewRegistry()))
+	e := httptest.New(t, newApp(m))
+	e.GET("/panic").Expect().Status(iris.StatusInternalServerError).JSON().Object().Value("code").IsEqual("100")
+	e.GET("/business").Expect().Status(iris.StatusInternalServerError)
+
+	if got := testutil.ToFloat64(m.requests.WithLabelValues("GET", "/panic", "500", "100")); got != 1 {
+		t.Fatalf("panic %v", got)
+	}
+	if got := testutil.ToFloat64(m.requests.WithLabelValues("GET", "/business", "500", "4399")); got != 1 {
+		t.Fatalf("business panic %v", got)
+	}
+	if got := testutil.ToFloat64(m.panics.WithLabelValues("/panic")); got != 1 {
+		t.Fatalf("panics %v", got)
+	}
+}
+
+func TestExpose(t *testing.T) {
+	m := New(WithRegistry(prometheus.NewRegistry()), WithAllowIPs())
+	e := httptest.New(t, newApp(m))
+	e.GET("/users/1").Expect()
+	e.GET("/metrics").Expect().Status(iris.StatusOK).Body().Contains(`iris_http_requests_total{code="",method="GET",route="/users/{id}",status="200"} 1`)
+
+	m = New(WithRegistry(prometheus.NewRegistry()), WithAllowIPs("10.0.0.1"))
+	httptest.New(t, newApp(m)).GET("/metrics").Expect().Status(iris.StatusForbidden).
+		JSON().Object().Value("code").IsEqual("4390")
+	if !m.Allowed("10.0.0.1") || m.Allowed("10.0.0.2") || m.Allowed("bad") {
+		t.Fatal("allowlist")
+	}
+}
@@ -0,0 +1,19 @@
+package pathRule
+
+import (
+	"regexp"
+)
+
+// Match reports whether the path rule of a middleware matches the request path,
+// a rule is an exact path, a *regexp.Regexp or a func(string) bool.
+func Match(name interface{}, currPath string) bool {
+	switch v := name.(type) {
+	case string:
+		return v == currPath
+	case *regexp.Regexp:
+		return v.MatchString(currPath)
+	case func(string) bool:
+		return v(currPath)
+	}
+	return false
+}
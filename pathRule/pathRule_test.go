@@ -0,0 +1,27 @@
+package pathRule
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		name  interface{}
+		path  string
+		match bool
+	}{
+		{"/login", "/login", true},
+		{"/login", "/login/", false},
+		{regexp.MustCompile(`^/admin/`), "/admin/users", true},
+		{regexp.MustCompile(`^/admin/`), "/api/admin/", false},
+		{func(p string) bool { return strings.HasSuffix(p, ".json") }, "/a.json", true},
+		{123, "/123", false},
+	}
+	for _, c := range cases {
+		if got := Match(c.name, c.path); got != c.match {
+			t.Errorf("Match(%v, %q) = %v, want %v", c.name, c.path, got, c.match)
+		}
+	}
+}
@@ -6,45 +6,232 @@ import (
 	baseError "github.com/go-tron/base-error"
 	"github.com/go-tron/config"
 	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/health"
 	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/context"
+	"net/http"
 	"reflect"
+	"runtime/debug"
+	"strconv"
+	"time"
 )
 
-func New() iris.Handler {
-	return baseContext.Handler(Recover())
+// maxReports bounds the reporter goroutines, panics beyond it aren't reported
+const maxReports = 64
+
+var (
+	ErrorBreakerOpen = baseError.Factory("4380", "service unavailable, retry after {}")
+)
+
+type Option func(*Config)
+
+func defaultConfig() *Config {
+	return &Config{
+		StatusCode:      http.StatusInternalServerError,
+		LogInterval:     time.Minute,
+		BreakerWindow:   time.Minute,
+		BreakerCooldown: 30 * time.Second,
+		CheckName:       "recover",
+	}
 }
 
-func Recover() func(ctx *baseContext.Context) {
-	return func(ctx *baseContext.Context) {
-		defer func() {
-			if err := recover(); err != nil {
-				if ctx.IsStopped() {
-					return
-				}
+func WithStatusCode(val int) Option {
+	return func(opts *Config) {
+		opts.StatusCode = val
+	}
+}
+func WithReporters(val ...PanicReporter) Option {
+	return func(opts *Config) {
+		opts.Reporters = append(opts.Reporters, val...)
+	}
+}
 
-				var e error
-				switch err.(type) {
-				case error:
-					e = baseError.WithStack(err.(error), 3)
-				default:
-					e = baseError.WithStack(errors.New(fmt.Sprint(err)), 3)
-				}
+// WithLogInterval logs the stack of identical panics at most once per interval, 0 logs every panic.
+func WithLogInterval(val time.Duration) Option {
+	return func(opts *Config) {
+		opts.LogInterval = val
+	}
+}
+
+// WithBreaker answers 503 on a route for the cooldown once it panicked threshold times within the window, threshold 0 disables it.
+func WithBreaker(threshold int, window time.Duration, cooldown time.Duration) Option {
+	return func(opts *Config) {
+		opts.BreakerThreshold = threshold
+		opts.BreakerWindow = window
+		opts.BreakerCooldown = cooldown
+	}
+}
+
+// WithHealth exposes the open breakers as a non critical readiness check.
+func WithHealth(val *health.Registry) Option {
+	return func(opts *Config) {
+		opts.Health = val
+	}
+}
+
+type Config struct {
+	StatusCode       int
+	Reporters        []PanicReporter
+	LogInterval      time.Duration
+	BreakerThreshold int
+	BreakerWindow    time.Duration
+	BreakerCooldown  time.Duration
+	Health           *health.Registry
+	CheckName        string
+}
+
+type Recoverer struct {
+	*Config
+	storm   *storm
+	reports chan struct{}
+}
+
+func NewRecoverer(opts ...Option) *Recoverer {
+	config := defaultConfig()
+	for _, apply := range opts {
+		apply(config)
+	}
+	if config.BreakerThreshold > 0 && (config.BreakerWindow <= 0 || config.BreakerCooldown <= 0) {
+		panic("BreakerWindow BreakerCooldown 必须设置")
+	}
+
+	r := &Recoverer{
+		Config:  config,
+		storm:   newStorm(),
+		reports: make(chan struct{}, maxReports),
+	}
+	if config.Health != nil {
+		config.Health.RegisterFunc(config.CheckName, r.storm.check, health.WithCritical(false), health.WithProbes(health.ProbeReadiness))
+	}
+	return r
+}
+
+func New(opts ...Option) iris.Handler {
+	return NewRecoverer(opts...).Handler()
+}
+
+func Recover(opts ...Option) func(ctx *baseContext.Context) {
+	return NewRecoverer(opts...).Context
+}
+
+func (r *Recoverer) Breakers() []BreakerState {
+	return r.storm.states()
+}
+
+// routeName keys the breakers by the registered route, before routing, e.g. with app.UseRouter, by the method and the path,
+// the breakers are bounded by the storm.
+func routeName(ctx *baseContext.Context) string {
+	if route := ctx.GetCurrentRoute(); route != nil {
+		return route.Method() + " " + route.Path()
+	}
+	return ctx.Method() + " " + ctx.Request().URL.Path
+}
+
+func (r *Recoverer) Context(ctx *baseContext.Context) {
+	var route string
+	if r.BreakerThreshold > 0 {
+		route = routeName(ctx)
+		if remaining, open := r.storm.open(route); open {
+			retryAfter := int(remaining/time.Second) + 1
+			ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+			ctx.StatusCode(http.StatusServiceUnavailable)
+			ctx.RecordEvent(baseContext.EventLimiterRejection, "breaker")
+			ctx.Error(ErrorBreakerOpen(strconv.Itoa(retryAfter) + "s"))
+			return
+		}
+	}
+
+	defer func() {
+		if err := recover(); err != nil {
+			if ctx.IsStopped() {
+				return
+			}
+			stack := debug.Stack()
+			//the handler index is gone once the execution is stopped
+			handler := ctx.HandlerName()
+			fp := fingerprint()
+			value := err
+
+			var e error
+			switch v := err.(type) {
+			case *baseContext.RecoveredPanic:
+				//re-panicked from another goroutine, e.g. by the timeout middleware
+				stack, value, fp = v.Stack, v.Value, fingerprintCallers(v.Callers)
+				e = v
+			case error:
+				e = baseError.WithStack(v, 3)
+			default:
+				e = baseError.WithStack(errors.New(fmt.Sprint(err)), 3)
+			}
+			count, log, suppressed := r.storm.seen(fp, r.LogInterval)
 
+			if log {
 				if ctx.Env != config.Production.String() {
-					console := fmt.Sprintf("Recover: %s\n", reflect.TypeOf(err))
-					console += fmt.Sprintf("%s\n", ctx.HandlerName())
+					console := fmt.Sprintf("Recover: %s fingerprint:%s count:%d suppressed:%d\n", reflect.TypeOf(value), fp, count, suppressed)
+					console += fmt.Sprintf("%s\n", handler)
 					console += fmt.Sprintf("%+v", e)
 					ctx.Application().Logger().Error(console)
 				}
-
 				ctx.Values().Set("error", e)
+			} else {
+				//the stack of this fingerprint was logged within the interval, the request log keeps the message only
+				ctx.Values().Set("error", ctx.PanicError(err))
+			}
+			ctx.Values().Set("panicFingerprint", fp)
+			ctx.RecordEvent(baseContext.EventPanic, fp)
+			ctx.StopExecution()
 
-				ctx.StatusCode(500)
-				ctx.StopExecution()
+			if r.BreakerThreshold > 0 && r.storm.trip(route, r.BreakerThreshold, r.BreakerWindow, r.BreakerCooldown) {
+				ctx.Application().Logger().Errorf("Recover: breaker open for %s during %s", route, r.BreakerCooldown)
 			}
-		}()
 
-		ctx.Next()
-	}
+			//headers may be sent already, only the status and the log are left then
+			if ctx.ResponseWriter().Written() == context.NoWritten {
+				ctx.StatusCode(r.StatusCode)
+				ctx.WriteError(ctx.PanicError(err))
+			}
 
+			if len(r.Reporters) > 0 {
+				info := newPanicInfo(ctx, value, e, stack)
+				info.Handler = handler
+				info.Fingerprint = fp
+				info.Count = count
+				select {
+				case r.reports <- struct{}{}:
+					go func() {
+						defer func() { <-r.reports }()
+						report(r.Reporters, info)
+					}()
+				default:
+					ctx.Application().Logger().Warnf("Recover: %d reports pending, panic %s not reported", maxReports, fp)
+				}
+			}
+		}
+	}()
+
+	ctx.Next()
+}
+
+func (r *Recoverer) Handler() iris.Handler {
+	return baseContext.Handler(r.Context)
+}
+
+func newPanicInfo(ctx *baseContext.Context, v interface{}, err error, stack []byte) *PanicInfo {
+	r := ctx.Request()
+	info := &PanicInfo{
+		Value:     v,
+		Error:     err,
+		Stack:     stack,
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Query:     r.URL.RawQuery,
+		IP:        ctx.GetIP(),
+		UserAgent: r.UserAgent(),
+		RequestId: ctx.Values().GetString("requestId"),
+		Time:      time.Now(),
+	}
+	if traceId := ctx.Values().Get("traceId"); traceId != nil {
+		info.TraceId = fmt.Sprint(traceId)
+	}
+	return info
 }
@@ -0,0 +1,121 @@
+package recover
+
+import (
+	"encoding/json"
+	baseError "github.com/go-tron/base-error"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/response"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func init() {
+	baseContext.New("test", nil, baseContext.WithResponse(response.New()))
+}
+
+var errorTest = baseError.Factory("4399", "test {}")
+
+func newApp(r *Recoverer) *iris.Application {
+	app := iris.New()
+	app.Logger().SetLevel("disable")
+	app.Use(r.Handler())
+	app.Get("/panic", func(ctx iris.Context) {
+		panic("boom")
+	})
+	app.Get("/business", func(ctx iris.Context) {
+		panic(errorTest("panic"))
+	})
+	app.Get("/written", func(ctx iris.Context) {
+		ctx.WriteString("partial")
+		ctx.ResponseWriter().Flush()
+		panic("late")
+	})
+	app.Get("/ok", func(ctx iris.Context) {
+		ctx.WriteString("ok")
+	})
+	return app
+}
+
+func TestEnvelope(t *testing.T) {
+	e := httptest.New(t, newApp(NewRecoverer()))
+	body := e.GET("/panic").Expect().Status(iris.StatusInternalServerError).JSON().Object()
+	body.Value("code").IsEqual("100")
+	body.Value("message").IsEqual("boom")
+
+	e.GET("/business").Expect().Status(iris.StatusInternalServerError).
+		JSON().Object().Value("code").IsEqual("4399")
+	e.GET("/written").Expect().Body().IsEqual("partial")
+	e.GET("/ok").Expect().Status(iris.StatusOK)
+}
+
+func TestStatusCode(t *testing.T) {
+	e := httptest.New(t, newApp(NewRecoverer(WithStatusCode(iris.StatusOK))))
+	e.GET("/panic").Expect().Status(iris.StatusOK).JSON().Object().Value("code").IsEqual("100")
+}
+
+func TestReporters(t *testing.T) {
+	reported := make(chan *PanicInfo, 2)
+	path := filepath.Join(t.TempDir(), "panics.log")
+	file := NewFileReporter(path)
+	r := NewRecoverer(WithReporters(
+		PanicReporterFunc(func(info *PanicInfo) { panic("reporter") }),
+		PanicReporterFunc(func(info *PanicInfo) { reported <- info }),
+		PanicReporterFunc(func(info *PanicInfo) {
+			file.Report(info)
+			reported <- info
+		}),
+	))
+	e := httptest.New(t, newApp(r))
+	e.GET("/panic").WithQuery("a", "1").Expect().Status(iris.StatusInternalServerError)
+
+	var info *PanicInfo
+	for i := 0; i < 2; i++ {
+		select {
+		case info = <-reported:
+		case <-time.After(time.Second):
+			t.Fatal("the panic was not reported")
+		}
+	}
+	if info.Message != "boom" || info.Handler == "" || info.Path != "/panic" || info.Query != "a=1" || info.Method != "GET" ||
+		info.Fingerprint == "" || info.Count != 1 || len(info.Stack) == 0 {
+		t.Fatalf("info %+v", info)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var line map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line["message"] != "boom" || line["stack"] == "" {
+		t.Fatalf("line %v", line)
+	}
+}
+
+func TestReportersBounded(t *testing.T) {
+	var started int64
+	release := make(chan struct{})
+	r := NewRecoverer(WithReporters(PanicReporterFunc(func(info *PanicInfo) {
+		atomic.AddInt64(&started, 1)
+		<-release
+	})))
+	app := newApp(r)
+	app.Logger().SetLevel("disable")
+	e := httptest.New(t, app)
+	for i := 0; i < maxReports+5; i++ {
+		e.GET("/panic").Expect().Status(iris.StatusInternalServerError)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt64(&started); n != maxReports {
+		t.Fatalf("reports %d", n)
+	}
+	close(release)
+}
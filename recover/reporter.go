@@ -0,0 +1,85 @@
+package recover
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-tron/iris/baseContext"
+	"os"
+	"sync"
+	"time"
+)
+
+type PanicInfo struct {
+	Value     interface{} `json:"-"`
+	Error     error       `json:"-"`
+	Message   string      `json:"message"`
+	Stack     []byte      `json:"-"`
+	Handler   string      `json:"handler"`
+	Method    string      `json:"method"`
+	Path      string      `json:"path"`
+	Query     string      `json:"query,omitempty"`
+	IP        string      `json:"ip"`
+	UserAgent string      `json:"userAgent,omitempty"`
+	RequestId string      `json:"requestId,omitempty"`
+	TraceId   string      `json:"traceId,omitempty"`
+	Time      time.Time   `json:"time"`
+	//Fingerprint identifies identical stacks, Count is the number of panics seen with it
+	Fingerprint string `json:"fingerprint"`
+	Count       int64  `json:"count"`
+}
+
+// PanicReporter forwards recovered panics, e.g. to an error tracker, reporters run outside the request goroutine.
+type PanicReporter interface {
+	Report(info *PanicInfo)
+}
+
+type PanicReporterFunc func(info *PanicInfo)
+
+func (f PanicReporterFunc) Report(info *PanicInfo) {
+	f(info)
+}
+
+func report(reporters []PanicReporter, info *PanicInfo) {
+	if info.Error != nil {
+		info.Message = info.Error.Error()
+	}
+	for _, reporter := range reporters {
+		baseContext.CallSafely(func() {
+			reporter.Report(info)
+		})
+	}
+}
+
+// FileReporter appends one JSON line per panic to the file.
+type FileReporter struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func NewFileReporter(path string) *FileReporter {
+	if path == "" {
+		panic("path 必须设置")
+	}
+	return &FileReporter{Path: path}
+}
+
+func (r *FileReporter) Report(info *PanicInfo) {
+	line := struct {
+		*PanicInfo
+		Stack string `json:"stack"`
+	}{info, string(info.Stack)}
+	data, err := json.Marshal(line)
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, err := os.OpenFile(r.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "panic reporter:", err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(data, '\n'))
+}
@@ -0,0 +1,190 @@
+package recover
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxFingerprints = 1000
+	maxBreakers     = 1000
+)
+
+type fingerprintStat struct {
+	count      int64
+	suppressed int64
+	lastLogged time.Time
+}
+
+type breaker struct {
+	panics    []time.Time
+	openUntil time.Time
+}
+
+type BreakerState struct {
+	Route     string    `json:"route"`
+	Open      bool      `json:"open"`
+	Panics    int       `json:"panics"`
+	OpenUntil time.Time `json:"openUntil,omitempty"`
+}
+
+type storm struct {
+	mu           sync.Mutex
+	fingerprints map[string]*fingerprintStat
+	breakers     map[string]*breaker
+}
+
+func newStorm() *storm {
+	return &storm{
+		fingerprints: make(map[string]*fingerprintStat),
+		breakers:     make(map[string]*breaker),
+	}
+}
+
+// fingerprint hashes the functions and lines of the panicking goroutine, runtime frames are skipped so that it doesn't depend on the call depth.
+func fingerprint() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	return fingerprintCallers(pcs[:n])
+}
+
+func fingerprintCallers(pcs []uintptr) string {
+	frames := runtime.CallersFrames(pcs)
+	h := fnv.New64a()
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") && !strings.Contains(frame.Function, "/recover.") {
+			h.Write([]byte(frame.Function))
+			h.Write([]byte(strconv.Itoa(frame.Line)))
+		}
+		if !more {
+			break
+		}
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// seen counts the panic and tells whether its stack should be logged, with the number of suppressed ones since the last log.
+func (s *storm) seen(fp string, interval time.Duration) (count int64, log bool, suppressed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stat, ok := s.fingerprints[fp]
+	if !ok {
+		if len(s.fingerprints) >= maxFingerprints {
+			s.fingerprints = make(map[string]*fingerprintStat)
+		}
+		stat = &fingerprintStat{}
+		s.fingerprints[fp] = stat
+	}
+	stat.count++
+	now := time.Now()
+	if interval > 0 && now.Sub(stat.lastLogged) < interval {
+		stat.suppressed++
+		return stat.count, false, 0
+	}
+	suppressed = stat.suppressed
+	stat.suppressed = 0
+	stat.lastLogged = now
+	return stat.count, true, suppressed
+}
+
+// open reports whether the breaker of the route is open and for how long.
+func (s *storm) open(route string) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.breakers[route]
+	if !ok {
+		return 0, false
+	}
+	if remaining := time.Until(b.openUntil); remaining > 0 {
+		return remaining, true
+	}
+	return 0, false
+}
+
+// trip records a panic of the route and opens the breaker once threshold panics happened within the window.
+func (s *storm) trip(route string, threshold int, window time.Duration, cooldown time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	b, ok := s.breakers[route]
+	if !ok {
+		if len(s.breakers) >= maxBreakers {
+			s.evict(now, window)
+			//every breaker is open or counting, the route isn't tracked rather than growing the map
+			if len(s.breakers) >= maxBreakers {
+				return false
+			}
+		}
+		b = &breaker{}
+		s.breakers[route] = b
+	}
+	panics := b.panics[:0]
+	for _, t := range b.panics {
+		if now.Sub(t) < window {
+			panics = append(panics, t)
+		}
+	}
+	b.panics = append(panics, now)
+	if len(b.panics) >= threshold {
+		b.panics = nil
+		b.openUntil = now.Add(cooldown)
+		return true
+	}
+	return false
+}
+
+// evict drops the closed breakers without panics within the window.
+func (s *storm) evict(now time.Time, window time.Duration) {
+	for route, b := range s.breakers {
+		if b.openUntil.After(now) {
+			continue
+		}
+		if n := len(b.panics); n == 0 || now.Sub(b.panics[n-1]) >= window {
+			delete(s.breakers, route)
+		}
+	}
+}
+
+func (s *storm) states() []BreakerState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	states := make([]BreakerState, 0, len(s.breakers))
+	for route, b := range s.breakers {
+		state := BreakerState{
+			Route:  route,
+			Open:   b.openUntil.After(now),
+			Panics: len(b.panics),
+		}
+		if state.Open {
+			state.OpenUntil = b.openUntil
+		}
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Route < states[j].Route
+	})
+	return states
+}
+
+// check fails while a breaker is open, it is registered as a non critical readiness check.
+func (s *storm) check(ctx context.Context) error {
+	var open []string
+	for _, state := range s.states() {
+		if state.Open {
+			open = append(open, state.Route)
+		}
+	}
+	if len(open) > 0 {
+		return errors.New("breaker open: " + strings.Join(open, ","))
+	}
+	return nil
+}
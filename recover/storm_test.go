@@ -0,0 +1,118 @@
+package recover
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-tron/iris/health"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogInterval(t *testing.T) {
+	logged := make(chan error, 4)
+	app := iris.New()
+	app.Logger().SetLevel("disable")
+	app.Use(func(ctx iris.Context) {
+		ctx.Next()
+		logged <- ctx.Values().Get("error").(error)
+	})
+	app.Use(NewRecoverer(WithLogInterval(time.Minute)).Handler())
+	app.Get("/panic", func(ctx iris.Context) {
+		panic("boom")
+	})
+	e := httptest.New(t, app)
+
+	e.GET("/panic").Expect().Status(iris.StatusInternalServerError)
+	if err := <-logged; !strings.Contains(fmt.Sprintf("%+v", err), "pool.go") {
+		t.Fatalf("the first panic was logged without its stack: %+v", err)
+	}
+	//identical panics within the interval keep the message only, whatever the environment
+	e.GET("/panic").Expect().Status(iris.StatusInternalServerError)
+	if err := <-logged; strings.Contains(fmt.Sprintf("%+v", err), "pool.go") || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("the stack was logged again: %+v", err)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	s := newStorm()
+	fp := func() string { return fingerprint() }
+	first := fp()
+	if second := fp(); first != second {
+		t.Fatalf("fingerprints %s %s", first, second)
+	}
+	if count, log, _ := s.seen(first, time.Minute); count != 1 || !log {
+		t.Fatalf("count %d log %v", count, log)
+	}
+	if count, log, _ := s.seen(first, time.Minute); count != 2 || log {
+		t.Fatalf("count %d log %v", count, log)
+	}
+	if count, log, suppressed := s.seen(first, 0); count != 3 || !log || suppressed != 1 {
+		t.Fatalf("count %d log %v suppressed %d", count, log, suppressed)
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	registry := health.NewRegistry()
+	r := NewRecoverer(WithBreaker(2, time.Minute, time.Minute), WithHealth(registry))
+	e := httptest.New(t, newApp(r))
+
+	e.GET("/panic").Expect().Status(iris.StatusInternalServerError)
+	e.GET("/panic").Expect().Status(iris.StatusInternalServerError)
+	resp := e.GET("/panic").Expect().Status(iris.StatusServiceUnavailable)
+	resp.Header("Retry-After").NotEmpty()
+	resp.JSON().Object().Value("code").IsEqual("4380")
+	//the other routes keep working
+	e.GET("/ok").Expect().Status(iris.StatusOK)
+
+	states := r.Breakers()
+	if len(states) != 1 || states[0].Route != "GET /panic" || !states[0].Open {
+		t.Fatalf("states %+v", states)
+	}
+	if report := registry.Run(context.Background(), health.ProbeReadiness); report.Status != health.StatusDegraded {
+		t.Fatalf("report %+v", report)
+	}
+}
+
+func TestBreakerUseRouter(t *testing.T) {
+	r := NewRecoverer(WithBreaker(2, time.Minute, time.Minute))
+	app := iris.New()
+	app.Logger().SetLevel("disable")
+	app.UseRouter(r.Handler())
+	app.UseRouter(func(ctx iris.Context) {
+		if ctx.Path() == "/bad" {
+			panic("router")
+		}
+		ctx.Next()
+	})
+	app.Get("/good", func(ctx iris.Context) {})
+	e := httptest.New(t, app)
+	e.GET("/bad").Expect().Status(iris.StatusInternalServerError)
+	e.GET("/bad").Expect().Status(iris.StatusInternalServerError)
+	e.GET("/bad").Expect().Status(iris.StatusServiceUnavailable)
+	//a panicking path doesn't open the breaker of the whole app
+	e.GET("/good").Expect().Status(iris.StatusOK)
+	if states := r.Breakers(); len(states) != 1 || states[0].Route != "GET /bad" {
+		t.Fatalf("states %+v", states)
+	}
+}
+
+func TestBreakersBounded(t *testing.T) {
+	s := newStorm()
+	for i := 0; i < maxBreakers+10; i++ {
+		s.trip(fmt.Sprintf("GET /%d", i), 100, time.Minute, time.Minute)
+	}
+	if len(s.breakers) != maxBreakers {
+		t.Fatalf("breakers %d", len(s.breakers))
+	}
+	//breakers without recent panics make room
+	s = newStorm()
+	for i := 0; i < maxBreakers+10; i++ {
+		s.trip(fmt.Sprintf("GET /%d", i), 100, time.Nanosecond, time.Minute)
+	}
+	if len(s.breakers) > maxBreakers {
+		t.Fatalf("breakers %d", len(s.breakers))
+	}
+}
@@ -1,11 +1,13 @@
 package requestLogger
 
 import (
+	"context"
 	baseError "github.com/go-tron/base-error"
 	"github.com/go-tron/iris/baseContext"
 	"github.com/go-tron/logger"
 	"github.com/kataras/iris/v12"
 	"github.com/thoas/go-funk"
+	"io"
 	"reflect"
 	"regexp"
 	"time"
@@ -15,10 +17,11 @@ type Option func(*Config)
 
 func defaultConfig() *Config {
 	return &Config{
-		IP:       true,
-		Query:    true,
-		Body:     true,
-		Response: false,
+		IP:        true,
+		Query:     true,
+		Body:      true,
+		BodyLimit: 8 << 10,
+		Response:  false,
 	}
 }
 
@@ -52,6 +55,14 @@ func WithBody(val bool) Option {
 		opts.Body = val
 	}
 }
+
+// WithBodyLimit bodies are read and logged up to val bytes, 8KB by default, the rest is cut off and the entry gets
+// body_truncated, 0 means unlimited.
+func WithBodyLimit(val int64) Option {
+	return func(opts *Config) {
+		opts.BodyLimit = val
+	}
+}
 func WithUserAgent(val bool) Option {
 	return func(opts *Config) {
 		opts.UserAgent = val
@@ -131,6 +142,7 @@ type Config struct {
 	IP          bool
 	Query       bool
 	Body        bool
+	BodyLimit   int64
 	Response    bool
 	UserAgent   bool
 	ContextKeys []string
@@ -149,6 +161,14 @@ func (l *RequestLogger) GetLogger() logger.Logger {
 	return l.logger
 }
 
+// Flush syncs the underlying logger when it supports it, used on shutdown.
+func (l *RequestLogger) Flush(ctx context.Context) error {
+	if syncer, ok := l.logger.(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+	return nil
+}
+
 func (l *RequestLogger) Context(ctx *baseContext.Context) {
 	level := l.CheckPath(ctx.Request().URL.Path)
 	if level == LevelIgnore {
@@ -211,6 +231,23 @@ func (l *RequestLogger) CheckPath(currPath string) Level {
 	return defaultLevel
 }
 
+// readBody never reads more than BodyLimit, a body rejected by the body limit isn't read whole for the log either.
+func (l *RequestLogger) readBody(ctx *baseContext.Context) ([]byte, bool) {
+	body := ctx.Request().Body
+	if body == nil {
+		return nil, false
+	}
+	if l.BodyLimit <= 0 {
+		data, _ := io.ReadAll(body)
+		return data, false
+	}
+	data, _ := io.ReadAll(io.LimitReader(body, l.BodyLimit+1))
+	if int64(len(data)) > l.BodyLimit {
+		return data[:l.BodyLimit], true
+	}
+	return data, false
+}
+
 func (l *RequestLogger) Log(ctx *baseContext.Context) {
 
 	startTime := ctx.Values().Get("startTime")
@@ -219,8 +256,6 @@ func (l *RequestLogger) Log(ctx *baseContext.Context) {
 		latency = time.Since(startTime.(time.Time)).Milliseconds()
 	}
 
-	requestBody, _ := ctx.GetBody()
-
 	fields := []*logger.Field{
 		l.logger.Field("time", startTime),
 		l.logger.Field("method", ctx.Request().Method),
@@ -238,7 +273,11 @@ func (l *RequestLogger) Log(ctx *baseContext.Context) {
 		fields = append(fields, l.logger.Field("query", ctx.Request().URL.RawQuery))
 	}
 	if l.Body {
+		requestBody, truncated := l.readBody(ctx)
 		fields = append(fields, l.logger.Field("body", requestBody))
+		if truncated {
+			fields = append(fields, l.logger.Field("body_truncated", true))
+		}
 	}
 	if l.UserAgent {
 		fields = append(fields, l.logger.Field("user-agent", ctx.GetHeader("user-agent")))
@@ -291,6 +330,9 @@ func (l *RequestLogger) Log(ctx *baseContext.Context) {
 	if traceId := ctx.Values().GetString("traceId"); traceId != "" {
 		fields = append(fields, l.logger.Field("trace_id", traceId))
 	}
+	if spanId := ctx.Values().GetString("spanId"); spanId != "" {
+		fields = append(fields, l.logger.Field("span_id", spanId))
+	}
 
 	ctxErr := ctx.Values().Get("error")
 	if ctxErr != nil {
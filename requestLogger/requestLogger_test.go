@@ -0,0 +1,139 @@
+package requestLogger
+
+import (
+	"bytes"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/response"
+	"github.com/go-tron/logger"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"sync"
+	"testing"
+)
+
+func init() {
+	baseContext.New("test", nil, baseContext.WithResponse(response.New()))
+}
+
+type entry struct {
+	level  string
+	fields map[string]interface{}
+}
+
+type testLogger struct {
+	mu      sync.Mutex
+	entries []entry
+}
+
+func (l *testLogger) log(level string, fields []*logger.Field) {
+	e := entry{level, make(map[string]interface{})}
+	for _, field := range fields {
+		e.fields[field.Key] = field.Value
+	}
+	l.mu.Lock()
+	l.entries = append(l.entries, e)
+	l.mu.Unlock()
+}
+
+func (l *testLogger) last(t *testing.T) entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) == 0 {
+		t.Fatal("nothing logged")
+	}
+	return l.entries[len(l.entries)-1]
+}
+
+func (l *testLogger) Level() string { return "debug" }
+func (l *testLogger) Field(key string, value interface{}) *logger.Field {
+	return logger.NewField(key, value)
+}
+func (l *testLogger) Debug(msg string, fields ...*logger.Field) { l.log("debug", fields) }
+func (l *testLogger) Info(msg string, fields ...*logger.Field)  { l.log("info", fields) }
+func (l *testLogger) Warn(msg string, fields ...*logger.Field)  { l.log("warn", fields) }
+func (l *testLogger) Error(msg string, fields ...*logger.Field) { l.log("error", fields) }
+func (l *testLogger) Fatal(msg string, fields ...*logger.Field) { l.log("fatal", fields) }
+
+func newApp(l *RequestLogger) *iris.Application {
+	app := iris.New()
+	app.Use(l.Handler())
+	app.Post("/ok", baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.RecordRequestBody(true)
+		var body map[string]interface{}
+		ctx.JSONReqBody(&body)
+		ctx.Success()
+	}))
+	app.Post("/fail", baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.Error(baseContext.ErrorReadParams("name"))
+	}))
+	return app
+}
+
+func TestLog(t *testing.T) {
+	l := &testLogger{}
+	e := httptest.New(t, newApp(New(l)))
+
+	e.POST("/ok").WithBytes([]byte(`{"name":"a"}`)).Expect().Status(iris.StatusOK)
+	entry := l.last(t)
+	if entry.level != "info" || entry.fields["path"] != "/ok" || entry.fields["status"] != iris.StatusOK {
+		t.Fatalf("entry %+v", entry)
+	}
+	if body, _ := entry.fields["body"].([]byte); string(body) != `{"name":"a"}` {
+		t.Fatalf("body %q", body)
+	}
+
+	e.POST("/fail").Expect()
+	if entry := l.last(t); entry.level != "warn" || entry.fields["error"] == nil {
+		t.Fatalf("entry %+v", entry)
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	l := &testLogger{}
+	e := httptest.New(t, newApp(New(l, WithBodyLimit(4))))
+
+	e.POST("/fail").WithBytes(bytes.Repeat([]byte("1"), 64)).Expect()
+	entry := l.last(t)
+	if body, _ := entry.fields["body"].([]byte); string(body) != "1111" {
+		t.Fatalf("body %q", body)
+	}
+	if entry.fields["body_truncated"] != true {
+		t.Fatalf("entry %+v", entry)
+	}
+
+	//0 means unlimited
+	l = &testLogger{}
+	e = httptest.New(t, newApp(New(l, WithBodyLimit(0))))
+	e.POST("/fail").WithBytes(bytes.Repeat([]byte("1"), 16<<10)).Expect()
+	entry = l.last(t)
+	if body, _ := entry.fields["body"].([]byte); len(body) != 16<<10 || entry.fields["body_truncated"] != nil {
+		t.Fatalf("body %d %+v", len(body), entry.fields["body_truncated"])
+	}
+}
+
+func TestIgnorePaths(t *testing.T) {
+	l := &testLogger{}
+	e := httptest.New(t, newApp(New(l, WithIgnorePaths("/ok"))))
+	e.POST("/ok").WithBytes([]byte(`{}`)).Expect().Status(iris.StatusOK)
+	if len(l.entries) != 0 {
+		t.Fatalf("entries %+v", l.entries)
+	}
+}
+
+func TestTraceIds(t *testing.T) {
+	l := &testLogger{}
+	app := iris.New()
+	app.Use(New(l).Handler())
+	app.Use(func(ctx iris.Context) {
+		ctx.Values().Set("traceId", "0af7651916cd43dd8448eb211c80319c")
+		ctx.Values().Set("spanId", "b7ad6b7169203331")
+		ctx.Next()
+	})
+	app.Get("/", func(ctx iris.Context) {})
+	httptest.New(t, app).GET("/").Expect().Status(iris.StatusOK)
+
+	entry := l.last(t)
+	if entry.fields["trace_id"] != "0af7651916cd43dd8448eb211c80319c" || entry.fields["span_id"] != "b7ad6b7169203331" {
+		t.Fatalf("entry %+v", entry)
+	}
+}
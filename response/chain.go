@@ -0,0 +1,47 @@
+package response
+
+import (
+	"encoding/json"
+	baseError "github.com/go-tron/base-error"
+	"github.com/go-tron/iris/baseContext"
+)
+
+const SuccessCode = "00"
+
+// Decode reads the envelope returned by a downstream service using this Response.
+func Decode(data []byte) (*Response, error) {
+	r := &Response{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Response) IsSuccess() bool {
+	return r.Code == SuccessCode
+}
+
+// ToError returns the error of the envelope as the downstream reported it, nil on success.
+func (r *Response) ToError() *baseError.Error {
+	if r.IsSuccess() {
+		return nil
+	}
+	e := baseError.New(r.Code, r.Message)
+	e.System = r.System
+	e.Chain = r.Chain
+	return e
+}
+
+// DecodeError decodes the body of a downstream call to service and returns its error chained with the application name,
+// nil when the downstream succeeded.
+func DecodeError(ctx *baseContext.Context, service string, data []byte) error {
+	r, err := Decode(data)
+	if err != nil {
+		return err
+	}
+	//a nil *baseError.Error must not become a non nil error
+	if e := r.ToError(); e != nil {
+		return ctx.ChainError(e, service)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package response
+
+import (
+	"github.com/go-tron/iris/baseContext"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"testing"
+)
+
+func init() {
+	baseContext.New("test", nil, baseContext.WithResponse(New()), baseContext.WithApplicationName("gateway"))
+}
+
+func TestToError(t *testing.T) {
+	r, err := Decode([]byte(`{"code":"00","message":""}`))
+	if err != nil || !r.IsSuccess() || r.ToError() != nil {
+		t.Fatalf("response %+v err %v", r, err)
+	}
+	r, _ = Decode([]byte(`{"code":"100","message":"down","system":true,"chain":"stock"}`))
+	if e := r.ToError(); e == nil || e.Code != "100" || e.Msg != "down" || !e.System || e.Chain != "stock" {
+		t.Fatalf("error %+v", e)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	app := iris.New()
+	app.Get("/", baseContext.Handler(func(ctx *baseContext.Context) {
+		if DecodeError(ctx, "order", []byte(`{"code":"00"}`)) != nil || DecodeError(ctx, "order", []byte(`{`)) == nil {
+			ctx.StatusCode(iris.StatusInternalServerError)
+			return
+		}
+		err := DecodeError(ctx, "order", []byte(`{"code":"1001","message":"bad"}`))
+		ctx.WriteError(ctx.BaseError(err))
+	}))
+	body := httptest.New(t, app).GET("/").Expect().Status(iris.StatusOK).JSON().Object()
+	body.Value("code").IsEqual("1001")
+	body.Value("chain").IsEqual("order<-gateway")
+}
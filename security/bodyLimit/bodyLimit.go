@@ -0,0 +1,95 @@
+package bodyLimit
+
+import (
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/pathRule"
+	"github.com/kataras/iris/v12"
+)
+
+type Option func(*Config)
+
+func defaultConfig() *Config {
+	return &Config{
+		Limit: 4 << 20,
+	}
+}
+
+func WithLimit(val int64) Option {
+	return func(opts *Config) {
+		opts.Limit = val
+	}
+}
+func WithPath(path interface{}, limit int64) Option {
+	return func(opts *Config) {
+		opts.Paths = append(opts.Paths, PathConfig{path, limit})
+	}
+}
+func WithPaths(paths ...PathConfig) Option {
+	return func(opts *Config) {
+		opts.Paths = append(opts.Paths, paths...)
+	}
+}
+func WithIgnorePaths(paths ...interface{}) Option {
+	return func(opts *Config) {
+		for _, path := range paths {
+			opts.Paths = append(opts.Paths, PathConfig{path, 0})
+		}
+	}
+}
+
+// PathConfig Limit 0 means unlimited.
+type PathConfig struct {
+	Name  interface{}
+	Limit int64
+}
+
+type Config struct {
+	Limit int64
+	Paths []PathConfig
+}
+
+type BodyLimit struct {
+	*Config
+}
+
+func New(opts ...Option) *BodyLimit {
+	config := defaultConfig()
+	for _, apply := range opts {
+		apply(config)
+	}
+	return &BodyLimit{
+		Config: config,
+	}
+}
+
+func (b *BodyLimit) CheckPath(currPath string) int64 {
+	var limit = b.Limit
+	for _, path := range b.Paths {
+		if pathRule.Match(path.Name, currPath) {
+			limit = path.Limit
+			break
+		}
+	}
+	return limit
+}
+
+func (b *BodyLimit) Context(ctx *baseContext.Context) {
+	limit := b.CheckPath(ctx.Request().URL.Path)
+	if limit <= 0 {
+		ctx.Next()
+		return
+	}
+
+	if ctx.Request().ContentLength > limit {
+		ctx.StatusCode(iris.StatusRequestEntityTooLarge)
+		ctx.Error(baseContext.ErrorBodyLimit(limit))
+		return
+	}
+	//chunked bodies are checked while reading, BaseError turns the read error into ErrorBodyLimit
+	ctx.SetMaxRequestBodySize(limit)
+	ctx.Next()
+}
+
+func (b *BodyLimit) Handler() iris.Handler {
+	return baseContext.Handler(b.Context)
+}
@@ -0,0 +1,56 @@
+package bodyLimit
+
+import (
+	"bytes"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/response"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"io"
+	"testing"
+)
+
+func init() {
+	baseContext.New("test", nil, baseContext.WithResponse(response.New()))
+}
+
+func newApp(b *BodyLimit) *iris.Application {
+	app := iris.New()
+	app.Use(b.Handler())
+	echo := baseContext.Handler(func(ctx *baseContext.Context) {
+		body, err := io.ReadAll(ctx.Request().Body)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		ctx.Write(body)
+	})
+	app.Post("/small", echo)
+	app.Post("/upload", echo)
+	return app
+}
+
+func TestContentLength(t *testing.T) {
+	e := httptest.New(t, newApp(New(WithLimit(8), WithIgnorePaths("/upload"))))
+	e.POST("/small").WithBytes([]byte("12345678")).Expect().Status(iris.StatusOK).Body().IsEqual("12345678")
+	e.POST("/small").WithBytes([]byte("123456789")).Expect().Status(iris.StatusRequestEntityTooLarge).
+		JSON().Object().Value("code").IsEqual("1003")
+	e.POST("/upload").WithBytes(bytes.Repeat([]byte("1"), 64)).Expect().Status(iris.StatusOK)
+}
+
+func TestChunked(t *testing.T) {
+	e := httptest.New(t, newApp(New(WithLimit(8))))
+	e.POST("/small").WithChunked(bytes.NewReader([]byte("1234"))).Expect().Status(iris.StatusOK).Body().IsEqual("1234")
+	e.POST("/small").WithChunked(bytes.NewReader(bytes.Repeat([]byte("1"), 64))).Expect().
+		Status(iris.StatusRequestEntityTooLarge).JSON().Object().Value("code").IsEqual("1003")
+}
+
+func TestCheckPath(t *testing.T) {
+	b := New(WithLimit(8), WithPath("/upload", 1<<20))
+	if limit := b.CheckPath("/upload"); limit != 1<<20 {
+		t.Fatalf("limit %d", limit)
+	}
+	if limit := b.CheckPath("/other"); limit != 8 {
+		t.Fatalf("limit %d", limit)
+	}
+}
@@ -0,0 +1,242 @@
+package concurrencyLimiter
+
+import (
+	"github.com/go-tron/base-error"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/pathRule"
+	"github.com/kataras/iris/v12"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrorOverloaded   = baseError.Factory("4310", "server overloaded, please retry later")
+	ErrorQueueTimeout = baseError.Factory("4311", "request queued over {}")
+)
+
+type Option func(*Config)
+
+func defaultConfig() *Config {
+	return &Config{
+		Limit:        100,
+		QueueSize:    100,
+		QueueTimeout: time.Second,
+		RetryAfter:   time.Second,
+	}
+}
+
+func WithLimit(val int) Option {
+	return func(opts *Config) {
+		opts.Limit = val
+	}
+}
+func WithQueueSize(val int) Option {
+	return func(opts *Config) {
+		opts.QueueSize = val
+	}
+}
+func WithQueueTimeout(val time.Duration) Option {
+	return func(opts *Config) {
+		opts.QueueTimeout = val
+	}
+}
+func WithRetryAfter(val time.Duration) Option {
+	return func(opts *Config) {
+		opts.RetryAfter = val
+	}
+}
+func WithAdaptive(val *Adaptive) Option {
+	return func(opts *Config) {
+		opts.Adaptive = val
+	}
+}
+func WithGroup(group *GroupConfig) Option {
+	return func(opts *Config) {
+		if group == nil {
+			panic("group 必须设置")
+		}
+		if group.Name == "" {
+			panic("group.Name 必须设置")
+		}
+		opts.Groups = append(opts.Groups, group)
+	}
+}
+func WithPath(path interface{}, level Level) Option {
+	return func(opts *Config) {
+		opts.Paths = append(opts.Paths, PathConfig{path, level})
+	}
+}
+func WithPaths(paths ...PathConfig) Option {
+	return func(opts *Config) {
+		opts.Paths = append(opts.Paths, paths...)
+	}
+}
+func WithBypassPaths(paths ...interface{}) Option {
+	return func(opts *Config) {
+		for _, path := range paths {
+			opts.Paths = append(opts.Paths, PathConfig{path, LevelBypass})
+		}
+	}
+}
+func WithHighPaths(paths ...interface{}) Option {
+	return func(opts *Config) {
+		for _, path := range paths {
+			opts.Paths = append(opts.Paths, PathConfig{path, LevelHigh})
+		}
+	}
+}
+func WithLowPaths(paths ...interface{}) Option {
+	return func(opts *Config) {
+		for _, path := range paths {
+			opts.Paths = append(opts.Paths, PathConfig{path, LevelLow})
+		}
+	}
+}
+
+type Level int
+
+const (
+	LevelBypass Level = iota
+	LevelHigh
+	LevelNormal
+	LevelLow
+)
+
+type PathConfig struct {
+	Name  interface{}
+	Level Level
+}
+
+type GroupConfig struct {
+	Name         string
+	Limit        int
+	QueueSize    int
+	QueueTimeout time.Duration
+	Adaptive     *Adaptive
+	Paths        []interface{}
+}
+
+type Config struct {
+	Limit        int
+	QueueSize    int
+	QueueTimeout time.Duration
+	RetryAfter   time.Duration
+	Adaptive     *Adaptive
+	Groups       []*GroupConfig
+	Paths        []PathConfig
+}
+
+type group struct {
+	*GroupConfig
+	limiter *Limiter
+}
+
+type ConcurrencyLimiter struct {
+	*Config
+	limiter *Limiter
+	groups  []*group
+}
+
+func New(opts ...Option) *ConcurrencyLimiter {
+	config := defaultConfig()
+	for _, apply := range opts {
+		apply(config)
+	}
+
+	cl := &ConcurrencyLimiter{
+		Config:  config,
+		limiter: NewLimiter("global", config.Limit, config.QueueSize, config.QueueTimeout, config.Adaptive),
+	}
+	for _, g := range config.Groups {
+		if g.QueueTimeout == 0 {
+			g.QueueTimeout = config.QueueTimeout
+		}
+		cl.groups = append(cl.groups, &group{
+			GroupConfig: g,
+			limiter:     NewLimiter(g.Name, g.Limit, g.QueueSize, g.QueueTimeout, g.Adaptive),
+		})
+	}
+	return cl
+}
+
+func (cl *ConcurrencyLimiter) CheckPath(currPath string) Level {
+	var level = LevelNormal
+	for _, path := range cl.Paths {
+		if pathRule.Match(path.Name, currPath) {
+			level = path.Level
+			break
+		}
+	}
+	return level
+}
+
+func (cl *ConcurrencyLimiter) CheckGroup(currPath string) *Limiter {
+	var limiter *Limiter
+	for _, g := range cl.groups {
+		for _, path := range g.Paths {
+			if pathRule.Match(path, currPath) {
+				limiter = g.limiter
+				break
+			}
+		}
+		if limiter != nil {
+			break
+		}
+	}
+	return limiter
+}
+
+func (cl *ConcurrencyLimiter) Stats() []Stats {
+	stats := []Stats{cl.limiter.Stats()}
+	for _, g := range cl.groups {
+		stats = append(stats, g.limiter.Stats())
+	}
+	return stats
+}
+
+func (cl *ConcurrencyLimiter) reject(ctx *baseContext.Context, err error) {
+	if cl.RetryAfter > 0 {
+		ctx.Header("Retry-After", strconv.Itoa(int((cl.RetryAfter+time.Second-1)/time.Second)))
+	}
+	ctx.StatusCode(iris.StatusServiceUnavailable)
+	ctx.RecordEvent(baseContext.EventLimiterRejection, "concurrency")
+	ctx.Error(err)
+}
+
+func (cl *ConcurrencyLimiter) Context(ctx *baseContext.Context) {
+	path := ctx.Request().URL.Path
+	level := cl.CheckPath(path)
+	if level == LevelBypass {
+		ctx.Next()
+		return
+	}
+
+	limiters := make([]*Limiter, 0, 2)
+	if g := cl.CheckGroup(path); g != nil {
+		limiters = append(limiters, g)
+	}
+	limiters = append(limiters, cl.limiter)
+
+	for i, limiter := range limiters {
+		if err := limiter.Acquire(ctx.Request().Context(), level); err != nil {
+			for _, acquired := range limiters[:i] {
+				acquired.Release(0)
+			}
+			cl.reject(ctx, err)
+			return
+		}
+	}
+
+	start := time.Now()
+	defer func() {
+		latency := time.Since(start)
+		for _, limiter := range limiters {
+			limiter.Release(latency)
+		}
+	}()
+	ctx.Next()
+}
+
+func (cl *ConcurrencyLimiter) Handler() iris.Handler {
+	return baseContext.Handler(cl.Context)
+}
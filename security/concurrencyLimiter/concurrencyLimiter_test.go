@@ -0,0 +1,161 @@
+package concurrencyLimiter
+
+import (
+	"context"
+	baseError "github.com/go-tron/base-error"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/response"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func init() {
+	baseContext.New("test", nil, baseContext.WithResponse(response.New()))
+}
+
+func errorCode(err error) string {
+	if e, ok := err.(*baseError.Error); ok {
+		return e.Code
+	}
+	return ""
+}
+
+func TestLimiterQueuesAndSheds(t *testing.T) {
+	l := NewLimiter("test", 1, 1, time.Second, nil)
+	if err := l.Acquire(context.Background(), LevelNormal); err != nil {
+		t.Fatal(err)
+	}
+
+	granted := make(chan error, 1)
+	go func() {
+		granted <- l.Acquire(context.Background(), LevelNormal)
+	}()
+	waitQueued(t, l, 1)
+
+	if err := l.Acquire(context.Background(), LevelNormal); errorCode(err) != "4310" {
+		t.Fatalf("full queue: got %v, want 4310", err)
+	}
+	l.Release(0)
+	if err := <-granted; err != nil {
+		t.Fatalf("queued request: %v", err)
+	}
+	if stats := l.Stats(); stats.InFlight != 1 || stats.Queued != 0 || stats.Shed != 1 {
+		t.Fatalf("stats: %+v", stats)
+	}
+}
+
+func TestLimiterQueueTimeout(t *testing.T) {
+	l := NewLimiter("test", 1, 1, 20*time.Millisecond, nil)
+	l.Acquire(context.Background(), LevelNormal)
+	if err := l.Acquire(context.Background(), LevelNormal); errorCode(err) != "4311" {
+		t.Fatalf("got %v, want 4311", err)
+	}
+}
+
+func TestLimiterEvictsLowerPriority(t *testing.T) {
+	l := NewLimiter("test", 1, 1, time.Second, nil)
+	l.Acquire(context.Background(), LevelNormal)
+
+	low := make(chan error, 1)
+	go func() {
+		low <- l.Acquire(context.Background(), LevelLow)
+	}()
+	waitQueued(t, l, 1)
+
+	high := make(chan error, 1)
+	go func() {
+		high <- l.Acquire(context.Background(), LevelHigh)
+	}()
+	if err := <-low; errorCode(err) != "4310" {
+		t.Fatalf("low priority: got %v, want 4310", err)
+	}
+	l.Release(0)
+	if err := <-high; err != nil {
+		t.Fatalf("high priority: %v", err)
+	}
+}
+
+func TestAdaptiveIsNotShared(t *testing.T) {
+	adaptive := &Adaptive{LatencyThreshold: time.Second}
+	NewLimiter("a", 10, 0, 0, adaptive)
+	NewLimiter("b", 50, 0, 0, adaptive)
+	if adaptive.MaxLimit != 0 || adaptive.MinLimit != 0 {
+		t.Fatalf("adaptive settings were modified: %+v", adaptive)
+	}
+}
+
+func TestAdaptiveDecreasesOnSlowRequests(t *testing.T) {
+	l := NewLimiter("test", 10, 0, 0, &Adaptive{LatencyThreshold: 10 * time.Millisecond, Decrease: 0.5})
+	l.Acquire(context.Background(), LevelNormal)
+	l.Release(time.Second)
+	if limit := l.Stats().Limit; limit != 5 {
+		t.Fatalf("limit %d, want 5", limit)
+	}
+}
+
+func waitQueued(t *testing.T, l *Limiter, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for l.Stats().Queued != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("queued %d, want %d", l.Stats().Queued, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	cl := New(WithLimit(1), WithQueueSize(0), WithBypassPaths("/health"))
+	release := make(chan struct{})
+	entered := make(chan struct{})
+
+	app := iris.New()
+	app.Use(cl.Handler())
+	app.Get("/slow", func(ctx iris.Context) {
+		close(entered)
+		<-release
+		ctx.WriteString("ok")
+	})
+	app.Get("/health", func(ctx iris.Context) {
+		ctx.WriteString("up")
+	})
+	e := httptest.New(t, app)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		e.GET("/slow").Expect().Status(iris.StatusOK)
+	}()
+	<-entered
+
+	resp := e.GET("/slow").Expect().Status(iris.StatusServiceUnavailable)
+	resp.Header("Retry-After").IsEqual("1")
+	resp.JSON().Object().Value("code").IsEqual("4310")
+	e.GET("/health").Expect().Status(iris.StatusOK).Body().IsEqual("up")
+
+	close(release)
+	wg.Wait()
+	if stats := cl.Stats()[0]; stats.InFlight != 0 {
+		t.Fatalf("in flight after release: %+v", stats)
+	}
+}
+
+func TestCheckPath(t *testing.T) {
+	cl := New(WithHighPaths("/admin"), WithGroup(&GroupConfig{Name: "upload", Limit: 2, Paths: []interface{}{"/upload"}}))
+	if level := cl.CheckPath("/admin"); level != LevelHigh {
+		t.Fatalf("level %d", level)
+	}
+	if level := cl.CheckPath("/other"); level != LevelNormal {
+		t.Fatalf("level %d", level)
+	}
+	if g := cl.CheckGroup("/upload"); g == nil || g.Stats().Name != "upload" {
+		t.Fatal("upload group not found")
+	}
+	if g := cl.CheckGroup("/other"); g != nil {
+		t.Fatal("unexpected group")
+	}
+}
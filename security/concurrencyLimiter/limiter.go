@@ -0,0 +1,208 @@
+package concurrencyLimiter
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type Adaptive struct {
+	MinLimit         int
+	MaxLimit         int
+	LatencyThreshold time.Duration
+	Increase         float64
+	Decrease         float64
+	DecreaseInterval time.Duration
+}
+
+type Stats struct {
+	Name     string `json:"name"`
+	Limit    int    `json:"limit"`
+	InFlight int    `json:"inFlight"`
+	Queued   int    `json:"queued"`
+	Shed     int64  `json:"shed"`
+}
+
+type waiter struct {
+	ready chan struct{}
+	shed  bool
+}
+
+type Limiter struct {
+	name         string
+	limit        float64
+	queueSize    int
+	queueTimeout time.Duration
+	adaptive     *Adaptive
+	mu           sync.Mutex
+	inFlight     int
+	queues       map[Level]*list.List
+	queued       int
+	shed         int64
+	lastDecrease time.Time
+}
+
+func NewLimiter(name string, limit int, queueSize int, queueTimeout time.Duration, adaptive *Adaptive) *Limiter {
+	if limit <= 0 {
+		panic("limit必须大于0")
+	}
+	if adaptive != nil {
+		//the same settings may be shared by several limiters, defaults go to a copy
+		copied := *adaptive
+		adaptive = &copied
+		if adaptive.MinLimit <= 0 {
+			adaptive.MinLimit = 1
+		}
+		if adaptive.MaxLimit < limit {
+			adaptive.MaxLimit = limit
+		}
+		if adaptive.LatencyThreshold == 0 {
+			panic("LatencyThreshold必须设置")
+		}
+		if adaptive.Increase == 0 {
+			adaptive.Increase = 1
+		}
+		if adaptive.Decrease == 0 {
+			adaptive.Decrease = 0.9
+		}
+		if adaptive.DecreaseInterval == 0 {
+			adaptive.DecreaseInterval = time.Second
+		}
+	}
+	return &Limiter{
+		name:         name,
+		limit:        float64(limit),
+		queueSize:    queueSize,
+		queueTimeout: queueTimeout,
+		adaptive:     adaptive,
+		queues: map[Level]*list.List{
+			LevelHigh:   list.New(),
+			LevelNormal: list.New(),
+			LevelLow:    list.New(),
+		},
+	}
+}
+
+func (l *Limiter) Acquire(ctx context.Context, level Level) error {
+	l.mu.Lock()
+	if l.inFlight < int(l.limit) && l.queued == 0 {
+		l.inFlight++
+		l.mu.Unlock()
+		return nil
+	}
+	if l.queueSize <= 0 || l.queueTimeout <= 0 {
+		l.shed++
+		l.mu.Unlock()
+		return ErrorOverloaded()
+	}
+	if l.queued >= l.queueSize && !l.evict(level) {
+		l.shed++
+		l.mu.Unlock()
+		return ErrorOverloaded()
+	}
+
+	w := &waiter{ready: make(chan struct{})}
+	elem := l.queues[level].PushBack(w)
+	l.queued++
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-w.ready:
+		if w.shed {
+			return ErrorOverloaded()
+		}
+		return nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-w.ready:
+		//granted or shed while timing out
+		if w.shed {
+			return ErrorOverloaded()
+		}
+		return nil
+	default:
+	}
+	l.queues[level].Remove(elem)
+	l.queued--
+	l.shed++
+	return ErrorQueueTimeout(l.queueTimeout)
+}
+
+// evict sheds the newest waiter with a lower priority than level to make room in a full queue.
+func (l *Limiter) evict(level Level) bool {
+	for lv := LevelLow; lv > level; lv-- {
+		queue := l.queues[lv]
+		if elem := queue.Back(); elem != nil {
+			w := queue.Remove(elem).(*waiter)
+			l.queued--
+			w.shed = true
+			close(w.ready)
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Limiter) Release(latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	if l.adaptive != nil && latency > 0 {
+		l.adapt(latency)
+	}
+
+	for l.inFlight < int(l.limit) && l.queued > 0 {
+		for _, lv := range []Level{LevelHigh, LevelNormal, LevelLow} {
+			queue := l.queues[lv]
+			if elem := queue.Front(); elem != nil {
+				w := queue.Remove(elem).(*waiter)
+				l.queued--
+				l.inFlight++
+				close(w.ready)
+				break
+			}
+		}
+	}
+}
+
+// adapt applies AIMD: additive increase while latency is healthy, multiplicative decrease when it is not.
+func (l *Limiter) adapt(latency time.Duration) {
+	a := l.adaptive
+	if latency > a.LatencyThreshold {
+		if time.Since(l.lastDecrease) < a.DecreaseInterval {
+			return
+		}
+		l.lastDecrease = time.Now()
+		l.limit = l.limit * a.Decrease
+		if l.limit < float64(a.MinLimit) {
+			l.limit = float64(a.MinLimit)
+		}
+		return
+	}
+	l.limit += a.Increase / l.limit
+	if l.limit > float64(a.MaxLimit) {
+		l.limit = float64(a.MaxLimit)
+	}
+}
+
+func (l *Limiter) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return Stats{
+		Name:     l.name,
+		Limit:    int(l.limit),
+		InFlight: l.inFlight,
+		Queued:   l.queued,
+		Shed:     l.shed,
+	}
+}
@@ -0,0 +1,330 @@
+package cors
+
+import (
+	"github.com/go-tron/base-error"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/pathRule"
+	"github.com/go-tron/iris/trace"
+	"github.com/kataras/iris/v12"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrorOriginNotAllowed = baseError.Factory("4330", "origin {} not allowed")
+	ErrorMethodNotAllowed = baseError.Factory("4331", "method {} not allowed")
+	ErrorHeaderNotAllowed = baseError.Factory("4332", "header {} not allowed")
+)
+
+type Option func(*Config)
+
+func DefaultPolicy() *Policy {
+	return &Policy{
+		AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD"},
+		AllowHeaders: []string{"Origin", "Accept", "Content-Type", "Authorization", "X-Requested-With"},
+		MaxAge:       10 * time.Minute,
+	}
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Default:         DefaultPolicy(),
+		RequestIdHeader: trace.DefaultRequestIdHeader,
+	}
+}
+
+// WithPolicy replaces the default policy built by WithAllowOrigins and the other policy options.
+func WithPolicy(policy *Policy) Option {
+	return func(opts *Config) {
+		if policy == nil {
+			panic("policy 必须设置")
+		}
+		opts.Policy = policy
+	}
+}
+func WithAllowOrigins(origins ...interface{}) Option {
+	return func(opts *Config) {
+		opts.Default.AllowOrigins = append(opts.Default.AllowOrigins, origins...)
+	}
+}
+func WithAllowMethods(methods ...string) Option {
+	return func(opts *Config) {
+		opts.Default.AllowMethods = methods
+	}
+}
+func WithAllowHeaders(headers ...string) Option {
+	return func(opts *Config) {
+		opts.Default.AllowHeaders = append(opts.Default.AllowHeaders, headers...)
+	}
+}
+func WithExposeHeaders(headers ...string) Option {
+	return func(opts *Config) {
+		opts.Default.ExposeHeaders = append(opts.Default.ExposeHeaders, headers...)
+	}
+}
+func WithAllowCredentials(val bool) Option {
+	return func(opts *Config) {
+		opts.Default.AllowCredentials = val
+	}
+}
+func WithMaxAge(val time.Duration) Option {
+	return func(opts *Config) {
+		opts.Default.MaxAge = val
+	}
+}
+
+// WithRequestIdHeader is allowed and exposed by every policy, set it to the header given to trace.WithHeader, empty disables it.
+func WithRequestIdHeader(val string) Option {
+	return func(opts *Config) {
+		opts.RequestIdHeader = val
+	}
+}
+func WithPath(path interface{}, policy *Policy) Option {
+	return func(opts *Config) {
+		opts.Paths = append(opts.Paths, PathConfig{path, policy})
+	}
+}
+func WithPaths(paths ...PathConfig) Option {
+	return func(opts *Config) {
+		opts.Paths = append(opts.Paths, paths...)
+	}
+}
+func WithIgnorePaths(paths ...interface{}) Option {
+	return func(opts *Config) {
+		for _, path := range paths {
+			opts.Paths = append(opts.Paths, PathConfig{path, nil})
+		}
+	}
+}
+
+// Policy AllowOrigins accepts "*", exact origins, wildcard subdomains like "https://*.example.com" and *regexp.Regexp.
+type Policy struct {
+	AllowOrigins     []interface{}
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+func (p *Policy) AllowOrigin(origin string) bool {
+	for _, allow := range p.AllowOrigins {
+		switch v := allow.(type) {
+		case string:
+			if v == "*" || strings.EqualFold(v, origin) {
+				return true
+			}
+			if i := strings.Index(v, "://*."); i != -1 {
+				v, origin := strings.ToLower(v), strings.ToLower(origin)
+				scheme, suffix := v[:i+3], v[i+4:]
+				if strings.HasPrefix(origin, scheme) && strings.HasSuffix(origin, suffix) && len(origin) > len(scheme)+len(suffix) {
+					return true
+				}
+			}
+		case *regexp.Regexp:
+			if v.MatchString(origin) {
+				return true
+			}
+		case func(string) bool:
+			if v(origin) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *Policy) anyOrigin() bool {
+	for _, allow := range p.AllowOrigins {
+		if v, ok := allow.(string); ok && v == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) clone() *Policy {
+	c := *p
+	c.AllowOrigins = append([]interface{}(nil), p.AllowOrigins...)
+	c.AllowMethods = append([]string(nil), p.AllowMethods...)
+	c.AllowHeaders = append([]string(nil), p.AllowHeaders...)
+	c.ExposeHeaders = append([]string(nil), p.ExposeHeaders...)
+	return &c
+}
+
+func (p *Policy) addHeader(header string) {
+	if !p.AllowHeader(header) {
+		p.AllowHeaders = append(p.AllowHeaders, header)
+	}
+	for _, expose := range p.ExposeHeaders {
+		if strings.EqualFold(expose, header) {
+			return
+		}
+	}
+	p.ExposeHeaders = append(p.ExposeHeaders, header)
+}
+
+func (p *Policy) AllowMethod(method string) bool {
+	for _, allow := range p.AllowMethods {
+		if allow == "*" || strings.EqualFold(allow, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) AllowHeader(header string) bool {
+	for _, allow := range p.AllowHeaders {
+		if allow == "*" || strings.EqualFold(allow, header) {
+			return true
+		}
+	}
+	return false
+}
+
+type PathConfig struct {
+	Name   interface{}
+	Policy *Policy
+}
+
+type Config struct {
+	Default         *Policy
+	Policy          *Policy
+	Paths           []PathConfig
+	RequestIdHeader string
+}
+
+type Cors struct {
+	*Config
+}
+
+// New should be registered with app.UseRouter so that preflight requests are answered before routing.
+func New(opts ...Option) *Cors {
+	config := defaultConfig()
+	for _, apply := range opts {
+		apply(config)
+	}
+	if config.Policy == nil {
+		config.Policy = config.Default
+	}
+	//the policies are copied since the request id header is added to them
+	config.Policy = config.Policy.clone()
+	policies := []*Policy{config.Policy}
+	paths := make([]PathConfig, len(config.Paths))
+	for i, path := range config.Paths {
+		if path.Policy != nil {
+			path.Policy = path.Policy.clone()
+			policies = append(policies, path.Policy)
+		}
+		paths[i] = path
+	}
+	config.Paths = paths
+	for _, policy := range policies {
+		//browsers would send the cookies of any site
+		if policy.anyOrigin() && policy.AllowCredentials {
+			panic("AllowOrigins * 不支持 AllowCredentials")
+		}
+		if config.RequestIdHeader != "" {
+			policy.addHeader(config.RequestIdHeader)
+		}
+	}
+	return &Cors{
+		Config: config,
+	}
+}
+
+func (c *Cors) CheckPath(currPath string) *Policy {
+	var policy = c.Policy
+	for _, path := range c.Paths {
+		if pathRule.Match(path.Name, currPath) {
+			policy = path.Policy
+			break
+		}
+	}
+	return policy
+}
+
+func (c *Cors) allowOriginHeader(policy *Policy, origin string) string {
+	if policy.anyOrigin() {
+		return "*"
+	}
+	return origin
+}
+
+func (c *Cors) preflight(ctx *baseContext.Context, policy *Policy, origin string) {
+	ctx.ResponseWriter().Header().Add("Vary", "Origin, Access-Control-Request-Method, Access-Control-Request-Headers")
+
+	if !policy.AllowOrigin(origin) {
+		ctx.StatusCode(iris.StatusForbidden)
+		ctx.Error(ErrorOriginNotAllowed(origin))
+		return
+	}
+	method := ctx.GetHeader("Access-Control-Request-Method")
+	if !policy.AllowMethod(method) {
+		ctx.StatusCode(iris.StatusForbidden)
+		ctx.Error(ErrorMethodNotAllowed(method))
+		return
+	}
+	var headers []string
+	for _, header := range strings.Split(ctx.GetHeader("Access-Control-Request-Headers"), ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+		if !policy.AllowHeader(header) {
+			ctx.StatusCode(iris.StatusForbidden)
+			ctx.Error(ErrorHeaderNotAllowed(header))
+			return
+		}
+		headers = append(headers, header)
+	}
+
+	ctx.Header("Access-Control-Allow-Origin", c.allowOriginHeader(policy, origin))
+	ctx.Header("Access-Control-Allow-Methods", strings.Join(policy.AllowMethods, ", "))
+	if len(headers) > 0 {
+		ctx.Header("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	}
+	if policy.AllowCredentials {
+		ctx.Header("Access-Control-Allow-Credentials", "true")
+	}
+	if policy.MaxAge > 0 {
+		ctx.Header("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge/time.Second)))
+	}
+	ctx.StopWithStatus(iris.StatusNoContent)
+}
+
+func (c *Cors) Context(ctx *baseContext.Context) {
+	origin := ctx.GetHeader("Origin")
+	if origin == "" {
+		ctx.Next()
+		return
+	}
+	policy := c.CheckPath(ctx.Request().URL.Path)
+	if policy == nil {
+		ctx.Next()
+		return
+	}
+
+	if ctx.Method() == iris.MethodOptions && ctx.GetHeader("Access-Control-Request-Method") != "" {
+		c.preflight(ctx, policy, origin)
+		return
+	}
+
+	ctx.ResponseWriter().Header().Add("Vary", "Origin")
+	if policy.AllowOrigin(origin) {
+		ctx.Header("Access-Control-Allow-Origin", c.allowOriginHeader(policy, origin))
+		if policy.AllowCredentials {
+			ctx.Header("Access-Control-Allow-Credentials", "true")
+		}
+		if len(policy.ExposeHeaders) > 0 {
+			ctx.Header("Access-Control-Expose-Headers", strings.Join(policy.ExposeHeaders, ", "))
+		}
+	}
+	ctx.Next()
+}
+
+func (c *Cors) Handler() iris.Handler {
+	return baseContext.Handler(c.Context)
+}
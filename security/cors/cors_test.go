@@ -0,0 +1,146 @@
+package cors
+
+import (
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/response"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"regexp"
+	"testing"
+)
+
+func init() {
+	baseContext.New("test", nil, baseContext.WithResponse(response.New()))
+}
+
+func newApp(c *Cors) *iris.Application {
+	app := iris.New()
+	app.UseRouter(c.Handler())
+	app.Get("/api", func(ctx iris.Context) {
+		ctx.WriteString("ok")
+	})
+	app.Get("/public", func(ctx iris.Context) {
+		ctx.WriteString("ok")
+	})
+	return app
+}
+
+func TestPreflight(t *testing.T) {
+	c := New(WithAllowOrigins("https://app.example.com", "https://*.example.org", regexp.MustCompile(`^https://[a-z]+\.test$`)),
+		WithAllowCredentials(true))
+	e := httptest.New(t, newApp(c))
+
+	for _, origin := range []string{"https://app.example.com", "https://a.example.org", "https://dev.test"} {
+		resp := e.OPTIONS("/api").
+			WithHeader("Origin", origin).
+			WithHeader("Access-Control-Request-Method", "POST").
+			WithHeader("Access-Control-Request-Headers", "content-type, x-request-id").
+			Expect().Status(iris.StatusNoContent)
+		resp.Header("Access-Control-Allow-Origin").IsEqual(origin)
+		resp.Header("Access-Control-Allow-Credentials").IsEqual("true")
+		resp.Header("Access-Control-Allow-Headers").IsEqual("content-type, x-request-id")
+		resp.Header("Access-Control-Max-Age").IsEqual("600")
+	}
+}
+
+func TestPreflightRejectionUsesEnvelope(t *testing.T) {
+	c := New(WithAllowOrigins("https://app.example.com"))
+	e := httptest.New(t, newApp(c))
+
+	e.OPTIONS("/api").
+		WithHeader("Origin", "https://evil.example.com").
+		WithHeader("Access-Control-Request-Method", "GET").
+		Expect().Status(iris.StatusForbidden).
+		JSON().Object().Value("code").IsEqual("4330")
+	e.OPTIONS("/api").
+		WithHeader("Origin", "https://app.example.com").
+		WithHeader("Access-Control-Request-Method", "TRACE").
+		Expect().Status(iris.StatusForbidden).
+		JSON().Object().Value("code").IsEqual("4331")
+	e.OPTIONS("/api").
+		WithHeader("Origin", "https://app.example.com").
+		WithHeader("Access-Control-Request-Method", "GET").
+		WithHeader("Access-Control-Request-Headers", "X-Secret").
+		Expect().Status(iris.StatusForbidden).
+		JSON().Object().Value("code").IsEqual("4332")
+}
+
+func TestSimpleRequest(t *testing.T) {
+	c := New(WithAllowOrigins("*"))
+	e := httptest.New(t, newApp(c))
+	resp := e.GET("/api").WithHeader("Origin", "https://any.example.com").Expect().Status(iris.StatusOK)
+	resp.Header("Access-Control-Allow-Origin").IsEqual("*")
+	resp.Header("Access-Control-Allow-Credentials").IsEmpty()
+	resp.Header("Access-Control-Expose-Headers").IsEqual("X-Request-Id")
+}
+
+func TestAnyOriginWithCredentialsPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("New accepted * with credentials")
+		}
+	}()
+	New(WithAllowOrigins("*"), WithAllowCredentials(true))
+}
+
+func TestRequestIdHeader(t *testing.T) {
+	c := New(WithAllowOrigins("https://app.example.com"), WithRequestIdHeader("X-Trace-Request"))
+	e := httptest.New(t, newApp(c))
+	e.GET("/api").WithHeader("Origin", "https://app.example.com").Expect().
+		Header("Access-Control-Expose-Headers").IsEqual("X-Trace-Request")
+	if !c.Policy.AllowHeader("x-trace-request") || c.Policy.AllowHeader("X-Request-Id") {
+		t.Fatalf("allow headers %v", c.Policy.AllowHeaders)
+	}
+}
+
+func TestIgnorePaths(t *testing.T) {
+	c := New(WithAllowOrigins("https://app.example.com"), WithIgnorePaths("/public"))
+	e := httptest.New(t, newApp(c))
+	e.GET("/public").WithHeader("Origin", "https://app.example.com").Expect().
+		Header("Access-Control-Allow-Origin").IsEmpty()
+}
+
+func TestPoliciesAreCopied(t *testing.T) {
+	policy := &Policy{AllowOrigins: []interface{}{"https://app.example.com"}, AllowMethods: []string{"GET"}}
+	path := &Policy{AllowOrigins: []interface{}{"https://app.example.com"}, AllowMethods: []string{"GET"}}
+	New(WithPolicy(policy), WithPath("/public", path))
+	New(WithPolicy(policy), WithPath("/public", path))
+	if len(policy.AllowHeaders) != 0 || len(policy.ExposeHeaders) != 0 || len(path.ExposeHeaders) != 0 {
+		t.Fatalf("policies modified %+v %+v", policy, path)
+	}
+}
+
+func TestOptionOrder(t *testing.T) {
+	policy := &Policy{AllowOrigins: []interface{}{"https://app.example.com"}, AllowMethods: []string{"GET"}}
+	for _, c := range []*Cors{
+		New(WithPolicy(policy), WithAllowOrigins("https://other.example.com")),
+		New(WithAllowOrigins("https://other.example.com"), WithPolicy(policy)),
+	} {
+		if !c.Policy.AllowOrigin("https://app.example.com") || c.Policy.AllowOrigin("https://other.example.com") {
+			t.Fatalf("origins %v", c.Policy.AllowOrigins)
+		}
+	}
+	if len(policy.AllowOrigins) != 1 {
+		t.Fatalf("origins %v", policy.AllowOrigins)
+	}
+}
+
+func TestVaryIsAppended(t *testing.T) {
+	app := iris.New()
+	app.UseRouter(func(ctx iris.Context) {
+		ctx.ResponseWriter().Header().Set("Vary", "Accept-Encoding")
+		ctx.Next()
+	})
+	app.UseRouter(New(WithAllowOrigins("https://app.example.com")).Handler())
+	app.Get("/api", func(ctx iris.Context) {})
+	e := httptest.New(t, app)
+	e.GET("/api").WithHeader("Origin", "https://app.example.com").Expect().
+		Headers().Value("Vary").IsEqual([]string{"Accept-Encoding", "Origin"})
+}
+
+func TestWildcardIsCaseInsensitive(t *testing.T) {
+	p := &Policy{AllowOrigins: []interface{}{"https://*.Example.org"}}
+	if !p.AllowOrigin("HTTPS://App.EXAMPLE.org") || p.AllowOrigin("https://example.org") {
+		t.Fatal("wildcard origin")
+	}
+}
@@ -0,0 +1,300 @@
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"github.com/go-tron/base-error"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/pathRule"
+	"github.com/kataras/iris/v12"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrorTokenInvalid  = baseError.Factory("4340", "csrf token invalid")
+	ErrorOriginInvalid = baseError.Factory("4341", "csrf origin {} not allowed")
+)
+
+const tokenContextKey = "csrfToken"
+
+type Mode int
+
+const (
+	ModeSession Mode = iota
+	ModeCookie
+)
+
+type Level int
+
+const (
+	LevelIgnore Level = iota
+	LevelVerify
+)
+
+type PathConfig struct {
+	Name  interface{}
+	Level Level
+}
+
+type Option func(*Config)
+
+func defaultConfig() *Config {
+	return &Config{
+		Mode:         ModeSession,
+		SessionKey:   "csrfToken",
+		CookieName:   "csrf_token",
+		CookieMaxAge: 12 * time.Hour,
+		HeaderName:   "X-CSRF-Token",
+		FormField:    "_csrf",
+		ViewDataKey:  "CsrfToken",
+		CheckOrigin:  true,
+	}
+}
+
+func WithMode(val Mode) Option {
+	return func(opts *Config) {
+		opts.Mode = val
+	}
+}
+func WithSessionKey(val string) Option {
+	return func(opts *Config) {
+		opts.SessionKey = val
+	}
+}
+func WithCookie(name string, maxAge time.Duration, secure bool) Option {
+	return func(opts *Config) {
+		opts.CookieName = name
+		opts.CookieMaxAge = maxAge
+		opts.CookieSecure = secure
+	}
+}
+func WithHeaderName(val string) Option {
+	return func(opts *Config) {
+		opts.HeaderName = val
+	}
+}
+func WithFormField(val string) Option {
+	return func(opts *Config) {
+		opts.FormField = val
+	}
+}
+func WithViewDataKey(val string) Option {
+	return func(opts *Config) {
+		opts.ViewDataKey = val
+	}
+}
+func WithCheckOrigin(val bool) Option {
+	return func(opts *Config) {
+		opts.CheckOrigin = val
+	}
+}
+func WithTrustedOrigins(origins ...string) Option {
+	return func(opts *Config) {
+		opts.TrustedOrigins = append(opts.TrustedOrigins, origins...)
+	}
+}
+func WithPath(path interface{}, level Level) Option {
+	return func(opts *Config) {
+		opts.Paths = append(opts.Paths, PathConfig{path, level})
+	}
+}
+func WithPaths(paths ...PathConfig) Option {
+	return func(opts *Config) {
+		opts.Paths = append(opts.Paths, paths...)
+	}
+}
+func WithIgnorePaths(paths ...interface{}) Option {
+	return func(opts *Config) {
+		for _, path := range paths {
+			opts.Paths = append(opts.Paths, PathConfig{path, LevelIgnore})
+		}
+	}
+}
+
+type Config struct {
+	Mode           Mode
+	SessionKey     string
+	CookieName     string
+	CookieMaxAge   time.Duration
+	CookieSecure   bool
+	HeaderName     string
+	FormField      string
+	ViewDataKey    string
+	CheckOrigin    bool
+	TrustedOrigins []string
+	Paths          []PathConfig
+}
+
+type Csrf struct {
+	*Config
+}
+
+func New(opts ...Option) *Csrf {
+	config := defaultConfig()
+	for _, apply := range opts {
+		apply(config)
+	}
+	return &Csrf{
+		Config: config,
+	}
+}
+
+func (c *Csrf) CheckPath(currPath string) Level {
+	var level = LevelVerify
+	for _, path := range c.Paths {
+		if pathRule.Match(path.Name, currPath) {
+			level = path.Level
+			break
+		}
+	}
+	return level
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// GetToken returns the token of the current request, use it to render forms or return it to api clients.
+func GetToken(ctx *baseContext.Context) string {
+	return ctx.Values().GetString(tokenContextKey)
+}
+
+func (c *Csrf) storedToken(ctx *baseContext.Context) (string, error) {
+	if c.Mode == ModeCookie {
+		return ctx.GetCookie(c.CookieName), nil
+	}
+	session := ctx.GetSession()
+	if session == nil {
+		return "", baseContext.ErrorSession("session not started")
+	}
+	return session.GetString(c.SessionKey), nil
+}
+
+func (c *Csrf) ensureToken(ctx *baseContext.Context) (string, error) {
+	token, err := c.storedToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	if token == "" {
+		if token, err = newToken(); err != nil {
+			return "", err
+		}
+		if c.Mode == ModeCookie {
+			ctx.SetCookie(&http.Cookie{
+				Name:     c.CookieName,
+				Value:    token,
+				Path:     "/",
+				MaxAge:   int(c.CookieMaxAge / time.Second),
+				Secure:   c.CookieSecure,
+				SameSite: http.SameSiteLaxMode,
+			})
+		} else {
+			ctx.GetSession().Set(c.SessionKey, token)
+		}
+	}
+	ctx.Values().Set(tokenContextKey, token)
+	if c.ViewDataKey != "" {
+		ctx.ViewData(c.ViewDataKey, token)
+	}
+	return token, nil
+}
+
+// requestToken reads the header or the form field of an urlencoded body, never the query which leaks into logs and referers,
+// multipart requests send the header so that the body isn't parsed before the token is verified.
+func (c *Csrf) requestToken(ctx *baseContext.Context) string {
+	if token := ctx.GetHeader(c.HeaderName); token != "" {
+		return token
+	}
+	if ctx.GetContentTypeRequested() != "application/x-www-form-urlencoded" {
+		return ""
+	}
+	return ctx.Request().PostFormValue(c.FormField)
+}
+
+func (c *Csrf) checkOrigin(ctx *baseContext.Context) error {
+	source := ctx.GetHeader("Origin")
+	if source == "" {
+		source = ctx.GetHeader("Referer")
+	}
+	if source == "" {
+		//browsers always send Referer for https form posts unless stripped, only require it on tls
+		if ctx.Request().TLS != nil {
+			return ErrorOriginInvalid("")
+		}
+		return nil
+	}
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" {
+		return ErrorOriginInvalid(source)
+	}
+	scheme := "http"
+	if ctx.Request().TLS != nil {
+		scheme = "https"
+	}
+	//behind a tls terminating proxy the public origin is added to TrustedOrigins
+	if strings.EqualFold(u.Scheme, scheme) && strings.EqualFold(u.Host, ctx.Host()) {
+		return nil
+	}
+	origin := u.Scheme + "://" + u.Host
+	for _, trusted := range c.TrustedOrigins {
+		if strings.EqualFold(trusted, origin) {
+			return nil
+		}
+	}
+	return ErrorOriginInvalid(origin)
+}
+
+func (c *Csrf) reject(ctx *baseContext.Context, err error) {
+	ctx.StatusCode(iris.StatusForbidden)
+	ctx.RecordEvent(baseContext.EventAuthFailure, "csrf")
+	if ctx.ViewError != "" && strings.Contains(ctx.GetHeader("Accept"), "text/html") {
+		ctx.ErrorView(err)
+		return
+	}
+	ctx.Error(err)
+}
+
+func (c *Csrf) Context(ctx *baseContext.Context) {
+	if c.CheckPath(ctx.Request().URL.Path) == LevelIgnore {
+		ctx.Next()
+		return
+	}
+
+	token, err := c.ensureToken(ctx)
+	if err != nil {
+		c.reject(ctx, err)
+		return
+	}
+
+	switch ctx.Method() {
+	case iris.MethodGet, iris.MethodHead, iris.MethodOptions, iris.MethodTrace:
+		ctx.Next()
+		return
+	}
+
+	if c.CheckOrigin {
+		if err := c.checkOrigin(ctx); err != nil {
+			c.reject(ctx, err)
+			return
+		}
+	}
+
+	requestToken := c.requestToken(ctx)
+	if requestToken == "" || subtle.ConstantTimeCompare([]byte(requestToken), []byte(token)) != 1 {
+		c.reject(ctx, ErrorTokenInvalid())
+		return
+	}
+	ctx.Next()
+}
+
+func (c *Csrf) Handler() iris.Handler {
+	return baseContext.Handler(c.Context)
+}
@@ -0,0 +1,95 @@
+package csrf
+
+import (
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/response"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/sessions"
+	"net/http"
+	"testing"
+	"testing/fstest"
+)
+
+func init() {
+	baseContext.New("test", nil, baseContext.WithResponse(response.New()), baseContext.WithViewError("error.html"))
+}
+
+func newApp(c *Csrf) *iris.Application {
+	app := iris.New()
+	app.Use(sessions.New(sessions.Config{Cookie: "sid"}).Handler())
+	app.RegisterView(iris.HTML(http.FS(fstest.MapFS{"error.html": {Data: []byte("error {{.Message}}")}}), ".html"))
+	app.Use(c.Handler())
+	token := baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.WriteString(GetToken(ctx))
+	})
+	app.Get("/form", token)
+	app.Post("/submit", token)
+	app.Post("/webhook", token)
+	return app
+}
+
+func newExpect(t *testing.T, c *Csrf) *httptest.Expect {
+	//the cookie jar needs a host, it is also the one same origin requests are checked against
+	return httptest.New(t, newApp(c), httptest.URL("http://example.com"))
+}
+
+func TestSessionToken(t *testing.T) {
+	e := newExpect(t, New())
+	token := e.GET("/form").Expect().Status(iris.StatusOK).Body().NotEmpty().Raw()
+
+	e.POST("/submit").Expect().Status(iris.StatusForbidden).
+		JSON().Object().Value("code").IsEqual("4340")
+	e.POST("/submit").WithHeader("X-CSRF-Token", "forged").Expect().Status(iris.StatusForbidden)
+	e.POST("/submit").WithHeader("X-CSRF-Token", token).Expect().Status(iris.StatusOK).Body().IsEqual(token)
+	e.POST("/submit").WithFormField("_csrf", token).Expect().Status(iris.StatusOK)
+}
+
+func TestCookieToken(t *testing.T) {
+	e := newExpect(t, New(WithMode(ModeCookie)))
+	resp := e.GET("/form").Expect().Status(iris.StatusOK)
+	token := resp.Body().Raw()
+	resp.Cookie("csrf_token").Value().IsEqual(token)
+
+	e.POST("/submit").WithHeader("X-CSRF-Token", token).Expect().Status(iris.StatusOK)
+	e.POST("/submit").WithCookie("csrf_token", "other").WithHeader("X-CSRF-Token", token).Expect().
+		Status(iris.StatusForbidden)
+}
+
+func TestOrigin(t *testing.T) {
+	e := newExpect(t, New(WithTrustedOrigins("https://admin.example.com")))
+	token := e.GET("/form").Expect().Body().Raw()
+
+	e.POST("/submit").WithHeader("X-CSRF-Token", token).WithHeader("Origin", "https://evil.example.com").Expect().
+		Status(iris.StatusForbidden).JSON().Object().Value("code").IsEqual("4341")
+	e.POST("/submit").WithHeader("X-CSRF-Token", token).WithHeader("Origin", "https://admin.example.com").Expect().
+		Status(iris.StatusOK)
+	e.POST("/submit").WithHeader("X-CSRF-Token", token).WithHeader("Referer", "http://example.com/form").Expect().
+		Status(iris.StatusOK)
+	//the scheme is part of the origin
+	e.POST("/submit").WithHeader("X-CSRF-Token", token).WithHeader("Origin", "https://example.com").Expect().
+		Status(iris.StatusForbidden).JSON().Object().Value("code").IsEqual("4341")
+}
+
+func TestTokenSource(t *testing.T) {
+	e := newExpect(t, New())
+	token := e.GET("/form").Expect().Body().Raw()
+
+	e.POST("/submit").WithQuery("_csrf", token).Expect().Status(iris.StatusForbidden)
+	e.POST("/submit").WithMultipart().WithFormField("_csrf", token).Expect().Status(iris.StatusForbidden)
+	e.POST("/submit").WithMultipart().WithFormField("_csrf", "x").WithHeader("X-CSRF-Token", token).Expect().
+		Status(iris.StatusOK)
+}
+
+func TestRejectionView(t *testing.T) {
+	e := newExpect(t, New())
+	e.GET("/form").Expect()
+	resp := e.POST("/submit").WithHeader("Accept", "text/html").Expect().Status(iris.StatusForbidden)
+	resp.Header("Content-Type").HasPrefix("text/html")
+	resp.Body().IsEqual("error [4340]csrf token invalid")
+}
+
+func TestIgnorePaths(t *testing.T) {
+	e := newExpect(t, New(WithIgnorePaths("/webhook")))
+	e.POST("/webhook").Expect().Status(iris.StatusOK)
+}
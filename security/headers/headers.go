@@ -0,0 +1,223 @@
+package headers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/pathRule"
+	"github.com/kataras/iris/v12"
+	"strings"
+	"time"
+)
+
+// NoncePlaceholder is replaced with a per request nonce in ContentSecurityPolicy, e.g. "script-src 'nonce-{nonce}'".
+const NoncePlaceholder = "{nonce}"
+
+const nonceContextKey = "cspNonce"
+
+type HSTS struct {
+	MaxAge            time.Duration
+	IncludeSubDomains bool
+	Preload           bool
+}
+
+func (h *HSTS) String() string {
+	value := fmt.Sprintf("max-age=%d", int(h.MaxAge/time.Second))
+	if h.IncludeSubDomains {
+		value += "; includeSubDomains"
+	}
+	if h.Preload {
+		value += "; preload"
+	}
+	return value
+}
+
+type Policy struct {
+	HSTS                      *HSTS
+	ContentSecurityPolicy     string
+	ReportOnly                bool
+	ContentTypeNosniff        bool
+	FrameOptions              string
+	ReferrerPolicy            string
+	PermissionsPolicy         string
+	CrossOriginOpenerPolicy   string
+	CrossOriginResourcePolicy string
+}
+
+func PresetAPI() *Policy {
+	return &Policy{
+		HSTS:                      &HSTS{MaxAge: 365 * 24 * time.Hour, IncludeSubDomains: true},
+		ContentSecurityPolicy:     "default-src 'none'; frame-ancestors 'none'",
+		ContentTypeNosniff:        true,
+		FrameOptions:              "DENY",
+		ReferrerPolicy:            "no-referrer",
+		PermissionsPolicy:         "camera=(), microphone=(), geolocation=()",
+		CrossOriginResourcePolicy: "same-origin",
+	}
+}
+
+func PresetHTML() *Policy {
+	return &Policy{
+		HSTS:                    &HSTS{MaxAge: 365 * 24 * time.Hour, IncludeSubDomains: true},
+		ContentSecurityPolicy:   "default-src 'self'; script-src 'self' 'nonce-" + NoncePlaceholder + "'; style-src 'self' 'nonce-" + NoncePlaceholder + "'; img-src 'self' data:; object-src 'none'; base-uri 'self'; frame-ancestors 'self'",
+		ContentTypeNosniff:      true,
+		FrameOptions:            "SAMEORIGIN",
+		ReferrerPolicy:          "strict-origin-when-cross-origin",
+		PermissionsPolicy:       "camera=(), microphone=(), geolocation=()",
+		CrossOriginOpenerPolicy: "same-origin",
+	}
+}
+
+type Option func(*Config)
+
+func defaultConfig() *Config {
+	return &Config{
+		Policy:      PresetAPI(),
+		ViewDataKey: "CspNonce",
+	}
+}
+
+func WithPolicy(policy *Policy) Option {
+	return func(opts *Config) {
+		if policy == nil {
+			panic("policy 必须设置")
+		}
+		opts.Policy = policy
+	}
+}
+
+// WithReportOnly sends the csp of the default policy as Content-Security-Policy-Report-Only, whichever policy it ends up being.
+func WithReportOnly(val bool) Option {
+	return func(opts *Config) {
+		opts.ReportOnly = val
+	}
+}
+func WithViewDataKey(val string) Option {
+	return func(opts *Config) {
+		opts.ViewDataKey = val
+	}
+}
+func WithPath(path interface{}, policy *Policy) Option {
+	return func(opts *Config) {
+		opts.Paths = append(opts.Paths, PathConfig{path, policy})
+	}
+}
+func WithPaths(paths ...PathConfig) Option {
+	return func(opts *Config) {
+		opts.Paths = append(opts.Paths, paths...)
+	}
+}
+func WithIgnorePaths(paths ...interface{}) Option {
+	return func(opts *Config) {
+		for _, path := range paths {
+			opts.Paths = append(opts.Paths, PathConfig{path, nil})
+		}
+	}
+}
+
+type PathConfig struct {
+	Name   interface{}
+	Policy *Policy
+}
+
+type Config struct {
+	Policy      *Policy
+	ReportOnly  bool
+	ViewDataKey string
+	Paths       []PathConfig
+}
+
+type Headers struct {
+	*Config
+}
+
+func New(opts ...Option) *Headers {
+	config := defaultConfig()
+	for _, apply := range opts {
+		apply(config)
+	}
+	if config.ReportOnly && !config.Policy.ReportOnly {
+		//copied so that a preset or a policy shared with other paths isn't changed
+		policy := *config.Policy
+		policy.ReportOnly = true
+		config.Policy = &policy
+	}
+	return &Headers{
+		Config: config,
+	}
+}
+
+func (h *Headers) CheckPath(currPath string) *Policy {
+	var policy = h.Policy
+	for _, path := range h.Paths {
+		if pathRule.Match(path.Name, currPath) {
+			policy = path.Policy
+			break
+		}
+	}
+	return policy
+}
+
+// GetNonce returns the csp nonce of the current request.
+func GetNonce(ctx *baseContext.Context) string {
+	return ctx.Values().GetString(nonceContextKey)
+}
+
+func newNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func (h *Headers) Context(ctx *baseContext.Context) {
+	policy := h.CheckPath(ctx.Request().URL.Path)
+	if policy == nil {
+		ctx.Next()
+		return
+	}
+
+	if policy.HSTS != nil {
+		ctx.Header("Strict-Transport-Security", policy.HSTS.String())
+	}
+	if csp := policy.ContentSecurityPolicy; csp != "" {
+		if strings.Contains(csp, NoncePlaceholder) {
+			nonce := newNonce()
+			csp = strings.ReplaceAll(csp, NoncePlaceholder, nonce)
+			ctx.Values().Set(nonceContextKey, nonce)
+			if h.ViewDataKey != "" {
+				ctx.ViewData(h.ViewDataKey, nonce)
+			}
+		}
+		if policy.ReportOnly {
+			ctx.Header("Content-Security-Policy-Report-Only", csp)
+		} else {
+			ctx.Header("Content-Security-Policy", csp)
+		}
+	}
+	if policy.ContentTypeNosniff {
+		ctx.Header("X-Content-Type-Options", "nosniff")
+	}
+	if policy.FrameOptions != "" {
+		ctx.Header("X-Frame-Options", policy.FrameOptions)
+	}
+	if policy.ReferrerPolicy != "" {
+		ctx.Header("Referrer-Policy", policy.ReferrerPolicy)
+	}
+	if policy.PermissionsPolicy != "" {
+		ctx.Header("Permissions-Policy", policy.PermissionsPolicy)
+	}
+	if policy.CrossOriginOpenerPolicy != "" {
+		ctx.Header("Cross-Origin-Opener-Policy", policy.CrossOriginOpenerPolicy)
+	}
+	if policy.CrossOriginResourcePolicy != "" {
+		ctx.Header("Cross-Origin-Resource-Policy", policy.CrossOriginResourcePolicy)
+	}
+	ctx.Next()
+}
+
+func (h *Headers) Handler() iris.Handler {
+	return baseContext.Handler(h.Context)
+}
@@ -0,0 +1,74 @@
+package headers
+
+import (
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/response"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func init() {
+	baseContext.New("test", nil, baseContext.WithResponse(response.New()))
+}
+
+func newApp(h *Headers) *iris.Application {
+	app := iris.New()
+	app.Use(h.Handler())
+	nonce := baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.WriteString(GetNonce(ctx))
+	})
+	app.Get("/api", nonce)
+	app.Get("/page", nonce)
+	app.Get("/health", nonce)
+	return app
+}
+
+func TestPresetAPI(t *testing.T) {
+	e := httptest.New(t, newApp(New()))
+	resp := e.GET("/api").Expect().Status(iris.StatusOK)
+	resp.Header("Strict-Transport-Security").IsEqual("max-age=31536000; includeSubDomains")
+	resp.Header("Content-Security-Policy").IsEqual("default-src 'none'; frame-ancestors 'none'")
+	resp.Header("X-Content-Type-Options").IsEqual("nosniff")
+	resp.Header("X-Frame-Options").IsEqual("DENY")
+	resp.Header("Cross-Origin-Resource-Policy").IsEqual("same-origin")
+	resp.Body().IsEmpty()
+}
+
+func TestNonce(t *testing.T) {
+	e := httptest.New(t, newApp(New(WithPolicy(PresetHTML()))))
+	first := e.GET("/page").Expect()
+	nonce := first.Body().NotEmpty().Raw()
+	first.Header("Content-Security-Policy").Contains("'nonce-" + nonce + "'")
+	if strings.Contains(first.Raw().Header.Get("Content-Security-Policy"), NoncePlaceholder) {
+		t.Fatal("placeholder left in the csp")
+	}
+	e.GET("/page").Expect().Body().NotEqual(nonce)
+}
+
+func TestReportOnly(t *testing.T) {
+	policy := PresetHTML()
+	for _, h := range []*Headers{
+		New(WithReportOnly(true), WithPolicy(policy)),
+		New(WithPolicy(policy), WithReportOnly(true)),
+	} {
+		resp := httptest.New(t, newApp(h)).GET("/page").Expect()
+		resp.Header("Content-Security-Policy").IsEmpty()
+		resp.Header("Content-Security-Policy-Report-Only").NotEmpty()
+	}
+	if policy.ReportOnly {
+		t.Fatal("the policy passed to WithPolicy was changed")
+	}
+}
+
+func TestPaths(t *testing.T) {
+	e := httptest.New(t, newApp(New(
+		WithPath(regexp.MustCompile("^/page"), PresetHTML()),
+		WithIgnorePaths("/health"),
+	)))
+	e.GET("/page").Expect().Header("X-Frame-Options").IsEqual("SAMEORIGIN")
+	e.GET("/api").Expect().Header("X-Frame-Options").IsEqual("DENY")
+	e.GET("/health").Expect().Header("X-Frame-Options").IsEmpty()
+}
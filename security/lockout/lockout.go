@@ -0,0 +1,319 @@
+package lockout
+
+import (
+	"github.com/go-tron/base-error"
+	"github.com/go-tron/config"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/kataras/iris/v12"
+	"net"
+	"strings"
+	"time"
+)
+
+var (
+	ErrorLocked = baseError.Factory("4320", "too many failed attempts, retry after {}")
+)
+
+type Option func(*Config)
+
+func defaultConfig() *Config {
+	return &Config{
+		Name:            "lockout",
+		MaxFailures:     5,
+		MaxIPFailures:   20,
+		Window:          15 * time.Minute,
+		LockDuration:    15 * time.Minute,
+		MaxLockDuration: 24 * time.Hour,
+		DelayAfter:      3,
+		Delay:           500 * time.Millisecond,
+		MaxDelay:        5 * time.Second,
+	}
+}
+
+func WithName(val string) Option {
+	return func(opts *Config) {
+		opts.Name = val
+	}
+}
+func WithStore(val Store) Option {
+	return func(opts *Config) {
+		opts.Store = val
+	}
+}
+func WithMaxFailures(val int) Option {
+	return func(opts *Config) {
+		opts.MaxFailures = val
+	}
+}
+func WithMaxIPFailures(val int) Option {
+	return func(opts *Config) {
+		opts.MaxIPFailures = val
+	}
+}
+func WithWindow(val time.Duration) Option {
+	return func(opts *Config) {
+		opts.Window = val
+	}
+}
+func WithLockDuration(val time.Duration, max time.Duration) Option {
+	return func(opts *Config) {
+		opts.LockDuration = val
+		opts.MaxLockDuration = max
+	}
+}
+
+// WithAccount reads the account of the request so that the middleware rejects locked accounts before the handler runs,
+// e.g. from a path parameter or a header.
+func WithAccount(val func(ctx *baseContext.Context) string) Option {
+	return func(opts *Config) {
+		opts.Account = val
+	}
+}
+
+// WithTrustedProxies resolves the client ip like GetIP when the remote address is one of the proxies, an ip or a CIDR,
+// otherwise every client behind the proxy shares one ip counter.
+func WithTrustedProxies(val ...string) Option {
+	return func(opts *Config) {
+		opts.TrustedProxies = val
+	}
+}
+
+// WithIP resolves the client ip of the ip counter, it takes precedence over WithTrustedProxies.
+func WithIP(val func(ctx *baseContext.Context) string) Option {
+	return func(opts *Config) {
+		opts.IP = val
+	}
+}
+func WithDelay(after int, delay time.Duration, max time.Duration) Option {
+	return func(opts *Config) {
+		opts.DelayAfter = after
+		opts.Delay = delay
+		opts.MaxDelay = max
+	}
+}
+
+type Config struct {
+	Name            string
+	Store           Store
+	MaxFailures     int
+	MaxIPFailures   int
+	Window          time.Duration
+	LockDuration    time.Duration
+	MaxLockDuration time.Duration
+	DelayAfter      int
+	Delay           time.Duration
+	MaxDelay        time.Duration
+	Account         func(ctx *baseContext.Context) string
+	TrustedProxies  []string
+	IP              func(ctx *baseContext.Context) string
+}
+
+type Lockout struct {
+	*Config
+	proxies []*net.IPNet
+}
+
+func NewWithConfig(conf *config.Config, opts ...Option) *Lockout {
+	return New(append([]Option{WithName(conf.GetString("application.name") + "-lockout")}, opts...)...)
+}
+
+func New(opts ...Option) *Lockout {
+	config := defaultConfig()
+	for _, apply := range opts {
+		apply(config)
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.Window == 0 {
+		panic("Window必须设置")
+	}
+	if config.LockDuration == 0 {
+		panic("LockDuration必须设置")
+	}
+	if config.MaxLockDuration < config.LockDuration {
+		config.MaxLockDuration = config.LockDuration
+	}
+	return &Lockout{Config: config, proxies: parseProxies(config.TrustedProxies)}
+}
+
+func parseProxies(ips []string) []*net.IPNet {
+	proxies := make([]*net.IPNet, 0, len(ips))
+	for _, ip := range ips {
+		if !strings.Contains(ip, "/") {
+			if parsed := net.ParseIP(ip); parsed != nil {
+				if parsed.To4() != nil {
+					ip += "/32"
+				} else {
+					ip += "/128"
+				}
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(ip)
+		if err != nil {
+			panic("TrustedProxies 不支持:" + ip)
+		}
+		proxies = append(proxies, ipNet)
+	}
+	return proxies
+}
+
+func (l *Lockout) accountKey(account string) string {
+	return l.Name + ":account:" + account
+}
+
+// clientIP uses the remote address, the X-REAL-IP header read by GetIP is trusted only from the proxies
+// since an attacker can rotate it.
+func (l *Lockout) clientIP(ctx *baseContext.Context) string {
+	if l.IP != nil {
+		return l.IP(ctx)
+	}
+	ip := ctx.RemoteAddr()
+	if parsed := net.ParseIP(ip); parsed != nil {
+		for _, proxy := range l.proxies {
+			if proxy.Contains(parsed) {
+				return ctx.GetIP()
+			}
+		}
+	}
+	return ip
+}
+
+func (l *Lockout) ipKey(ctx *baseContext.Context) string {
+	return l.Name + ":ip:" + l.clientIP(ctx)
+}
+
+func (l *Lockout) lockedError(ctx *baseContext.Context, key string) error {
+	d, err := l.Store.LockedFor(key)
+	if err != nil {
+		ctx.Application().Logger().Error("lockout store:", err)
+		return nil
+	}
+	if d > 0 {
+		ctx.StatusCode(iris.StatusTooManyRequests)
+		return ErrorLocked(d.Round(time.Second))
+	}
+	return nil
+}
+
+func (l *Lockout) Check(ctx *baseContext.Context, account string) error {
+	if account != "" {
+		if err := l.lockedError(ctx, l.accountKey(account)); err != nil {
+			return err
+		}
+	}
+	return l.lockedError(ctx, l.ipKey(ctx))
+}
+
+// lock locks the key, every further lockout within MaxLockDuration doubles the duration.
+func (l *Lockout) lock(key string) (time.Duration, error) {
+	times, err := l.Store.Incr(key+":times", l.MaxLockDuration)
+	if err != nil {
+		return 0, err
+	}
+	d := l.LockDuration
+	for i := 1; i < times && d < l.MaxLockDuration; i++ {
+		d *= 2
+	}
+	if d > l.MaxLockDuration {
+		d = l.MaxLockDuration
+	}
+	if err := l.Store.Lock(key, d); err != nil {
+		return 0, err
+	}
+	if err := l.Store.Reset(key); err != nil {
+		return d, err
+	}
+	return d, nil
+}
+
+func (l *Lockout) failure(ctx *baseContext.Context, key string, max int) (int, error) {
+	times, err := l.Store.Incr(key, l.Window)
+	if err != nil {
+		ctx.Application().Logger().Error("lockout store:", err)
+		return 0, nil
+	}
+	if max > 0 && times >= max {
+		d, err := l.lock(key)
+		if err != nil {
+			ctx.Application().Logger().Error("lockout store:", err)
+		}
+		//the key is locked even when resetting its counter failed
+		if d == 0 {
+			return times, nil
+		}
+		ctx.StatusCode(iris.StatusTooManyRequests)
+		return times, ErrorLocked(d.Round(time.Second))
+	}
+	return times, nil
+}
+
+func (l *Lockout) delay(ctx *baseContext.Context, times int) {
+	if l.Delay <= 0 || times <= l.DelayAfter {
+		return
+	}
+	d := l.Delay
+	for i := l.DelayAfter + 1; i < times && d < l.MaxDelay; i++ {
+		d *= 2
+	}
+	if l.MaxDelay > 0 && d > l.MaxDelay {
+		d = l.MaxDelay
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Request().Context().Done():
+	}
+}
+
+// Failure counts the failure for the account and the ip, both are counted even when one of them gets locked.
+func (l *Lockout) Failure(ctx *baseContext.Context, account string) error {
+	var (
+		times      int
+		accountErr error
+	)
+	if account != "" {
+		times, accountErr = l.failure(ctx, l.accountKey(account), l.MaxFailures)
+	}
+	n, ipErr := l.failure(ctx, l.ipKey(ctx), l.MaxIPFailures)
+	if accountErr != nil {
+		return accountErr
+	}
+	if ipErr != nil {
+		return ipErr
+	}
+	if account == "" {
+		times = n
+	}
+	l.delay(ctx, times)
+	return nil
+}
+
+// Success resets the account counter, the ip counter keeps counting so that an attacker can't reset it with an own account.
+func (l *Lockout) Success(ctx *baseContext.Context, account string) {
+	if account == "" {
+		return
+	}
+	if err := l.Store.Reset(l.accountKey(account)); err != nil {
+		ctx.Application().Logger().Error("lockout store:", err)
+	}
+}
+
+func (l *Lockout) Context(ctx *baseContext.Context) {
+	var account string
+	if l.Account != nil {
+		account = l.Account(ctx)
+	}
+	if err := l.Check(ctx, account); err != nil {
+		ctx.RecordEvent(baseContext.EventLimiterRejection, "lockout")
+		ctx.Error(err)
+		return
+	}
+	ctx.SetAuthReporter(l)
+	ctx.Next()
+}
+
+func (l *Lockout) Handler() iris.Handler {
+	return baseContext.Handler(l.Context)
+}
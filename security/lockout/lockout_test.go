@@ -0,0 +1,164 @@
+package lockout
+
+import (
+	"errors"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/response"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"testing"
+	"time"
+)
+
+func init() {
+	baseContext.New("test", nil, baseContext.WithResponse(response.New()))
+}
+
+func newApp(l *Lockout) *iris.Application {
+	app := iris.New()
+	app.Use(l.Handler())
+	app.Post("/login/{account}", baseContext.Handler(func(ctx *baseContext.Context) {
+		account := ctx.Params().Get("account")
+		if ctx.URLParam("password") == "secret" {
+			ctx.ReportAuthSuccess(account)
+			ctx.Success()
+			return
+		}
+		if err := ctx.ReportAuthFailure(account); err != nil {
+			ctx.Error(err)
+			return
+		}
+		ctx.StatusCode(iris.StatusUnauthorized)
+		ctx.WriteString("wrong password")
+	}))
+	return app
+}
+
+func newLockout(opts ...Option) *Lockout {
+	return New(append([]Option{
+		WithMaxFailures(3),
+		WithMaxIPFailures(100),
+		WithDelay(100, 0, 0),
+	}, opts...)...)
+}
+
+func TestAccountLockReturns429(t *testing.T) {
+	e := httptest.New(t, newApp(newLockout()))
+	e.POST("/login/bob").Expect().Status(iris.StatusUnauthorized)
+	e.POST("/login/bob").Expect().Status(iris.StatusUnauthorized)
+	e.POST("/login/bob").Expect().Status(iris.StatusTooManyRequests).
+		JSON().Object().Value("code").IsEqual("4320")
+}
+
+func TestMiddlewareRejectsLockedAccount(t *testing.T) {
+	l := newLockout(WithAccount(func(ctx *baseContext.Context) string {
+		return ctx.Params().Get("account")
+	}))
+	e := httptest.New(t, newApp(l))
+	for i := 0; i < 3; i++ {
+		e.POST("/login/bob").Expect()
+	}
+	//the right password doesn't get through while the account is locked
+	e.POST("/login/bob").WithQuery("password", "secret").Expect().Status(iris.StatusTooManyRequests).
+		JSON().Object().Value("code").IsEqual("4320")
+	e.POST("/login/alice").WithQuery("password", "secret").Expect().Status(iris.StatusOK)
+}
+
+func TestIPIsCountedWhenAccountLocks(t *testing.T) {
+	store := NewMemoryStore()
+	l := newLockout(WithStore(store), WithMaxFailures(1))
+	e := httptest.New(t, newApp(l))
+	e.POST("/login/bob").Expect().Status(iris.StatusTooManyRequests)
+	if n, _ := store.Incr(l.Name+":ip:", l.Window); n != 2 {
+		t.Fatalf("ip failures %d, want 1 before this increment", n-1)
+	}
+}
+
+func TestIPLockIgnoresRealIPHeader(t *testing.T) {
+	l := newLockout(WithMaxIPFailures(2), WithMaxFailures(0))
+	e := httptest.New(t, newApp(l))
+	e.POST("/login/a").WithHeader("X-Real-IP", "10.0.0.1").Expect().Status(iris.StatusUnauthorized)
+	e.POST("/login/b").WithHeader("X-Real-IP", "10.0.0.2").Expect().Status(iris.StatusTooManyRequests)
+	e.POST("/login/c").WithHeader("X-Real-IP", "10.0.0.3").Expect().Status(iris.StatusTooManyRequests)
+}
+
+func TestSuccessResetsAccount(t *testing.T) {
+	e := httptest.New(t, newApp(newLockout()))
+	e.POST("/login/bob").Expect().Status(iris.StatusUnauthorized)
+	e.POST("/login/bob").Expect().Status(iris.StatusUnauthorized)
+	e.POST("/login/bob").WithQuery("password", "secret").Expect().Status(iris.StatusOK)
+	e.POST("/login/bob").Expect().Status(iris.StatusUnauthorized)
+	e.POST("/login/bob").Expect().Status(iris.StatusUnauthorized)
+}
+
+func TestLockDurationDoubles(t *testing.T) {
+	l := newLockout(WithLockDuration(time.Minute, time.Hour))
+	first, _ := l.lock("k")
+	second, _ := l.lock("k")
+	if first != time.Minute || second != 2*time.Minute {
+		t.Fatalf("lock durations %s, %s", first, second)
+	}
+}
+
+func TestTrustedProxySeparatesClients(t *testing.T) {
+	l := newLockout(WithMaxIPFailures(2), WithMaxFailures(0), WithTrustedProxies("10.0.0.0/8"))
+	app := newApp(l)
+	app.UseRouter(func(ctx iris.Context) {
+		ctx.Request().RemoteAddr = "10.0.0.9:4000"
+		ctx.Next()
+	})
+	e := httptest.New(t, app)
+	e.POST("/login/a").WithHeader("X-Real-IP", "1.1.1.1").Expect().Status(iris.StatusUnauthorized)
+	e.POST("/login/a").WithHeader("X-Real-IP", "1.1.1.1").Expect().Status(iris.StatusTooManyRequests)
+	//the other client behind the same proxy isn't locked out
+	e.POST("/login/b").WithHeader("X-Real-IP", "2.2.2.2").Expect().Status(iris.StatusUnauthorized)
+
+	//the header isn't trusted from other addresses
+	l = newLockout(WithMaxIPFailures(2), WithMaxFailures(0), WithTrustedProxies("192.168.0.1"))
+	app = newApp(l)
+	app.UseRouter(func(ctx iris.Context) {
+		ctx.Request().RemoteAddr = "10.0.0.9:4000"
+		ctx.Next()
+	})
+	e = httptest.New(t, app)
+	e.POST("/login/a").WithHeader("X-Real-IP", "1.1.1.1").Expect().Status(iris.StatusUnauthorized)
+	e.POST("/login/b").WithHeader("X-Real-IP", "2.2.2.2").Expect().Status(iris.StatusTooManyRequests)
+}
+
+func TestIPExtractor(t *testing.T) {
+	l := newLockout(WithMaxIPFailures(1), WithMaxFailures(0), WithIP(func(ctx *baseContext.Context) string {
+		return ctx.GetHeader("X-Client")
+	}))
+	e := httptest.New(t, newApp(l))
+	e.POST("/login/a").WithHeader("X-Client", "1").Expect().Status(iris.StatusTooManyRequests)
+	e.POST("/login/a").WithHeader("X-Client", "2").Expect().Status(iris.StatusTooManyRequests)
+	e.POST("/login/a").WithHeader("X-Client", "1").WithQuery("password", "secret").Expect().Status(iris.StatusTooManyRequests)
+}
+
+func TestFailureRoundsDuration(t *testing.T) {
+	e := httptest.New(t, newApp(newLockout(WithMaxFailures(1), WithLockDuration(1500*time.Millisecond, time.Minute))))
+	e.POST("/login/bob").Expect().Status(iris.StatusTooManyRequests).Body().Contains("retry after 2s")
+}
+
+type resetErrorStore struct {
+	*MemoryStore
+}
+
+func (s resetErrorStore) Reset(key string) error {
+	return errors.New("reset failed")
+}
+
+func TestLockResetError(t *testing.T) {
+	l := newLockout(WithStore(resetErrorStore{NewMemoryStore()}))
+	if d, err := l.lock("k"); d != l.LockDuration || err == nil {
+		t.Fatalf("lock %s %v", d, err)
+	}
+	//the account is locked even though its counter wasn't reset
+	app := newApp(newLockout(WithStore(resetErrorStore{NewMemoryStore()}), WithMaxFailures(1), WithAccount(func(ctx *baseContext.Context) string {
+		return ctx.Params().Get("account")
+	})))
+	app.Logger().SetLevel("disable")
+	e := httptest.New(t, app)
+	e.POST("/login/bob").Expect().Status(iris.StatusTooManyRequests)
+	e.POST("/login/bob").WithQuery("password", "secret").Expect().Status(iris.StatusTooManyRequests)
+}
@@ -0,0 +1,125 @@
+package lockout
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"time"
+)
+
+type Store interface {
+	Incr(key string, window time.Duration) (int, error)
+	Lock(key string, duration time.Duration) error
+	LockedFor(key string) (time.Duration, error)
+	Reset(key string) error
+}
+
+type entry struct {
+	count    int
+	expireAt time.Time
+}
+
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	sweepAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*entry)}
+}
+
+func (s *MemoryStore) get(key string, now time.Time) *entry {
+	if now.After(s.sweepAt) {
+		for k, e := range s.entries {
+			if now.After(e.expireAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.sweepAt = now.Add(time.Minute)
+	}
+	e, ok := s.entries[key]
+	if !ok || now.After(e.expireAt) {
+		return nil
+	}
+	return e
+}
+
+func (s *MemoryStore) Incr(key string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	e := s.get(key, now)
+	if e == nil {
+		e = &entry{expireAt: now.Add(window)}
+		s.entries[key] = e
+	}
+	e.count++
+	return e.count, nil
+}
+
+func (s *MemoryStore) Lock(key string, duration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key+":lock"] = &entry{count: 1, expireAt: time.Now().Add(duration)}
+	return nil
+}
+
+func (s *MemoryStore) LockedFor(key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if e := s.get(key+":lock", now); e != nil {
+		return e.expireAt.Sub(now), nil
+	}
+	return 0, nil
+}
+
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+var incrScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+type RedisStore struct {
+	Client redis.UniversalClient
+}
+
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	if client == nil {
+		panic("client 必须设置")
+	}
+	return &RedisStore{Client: client}
+}
+
+func (s *RedisStore) Incr(key string, window time.Duration) (int, error) {
+	return incrScript.Run(context.Background(), s.Client, []string{key}, window.Milliseconds()).Int()
+}
+
+func (s *RedisStore) Lock(key string, duration time.Duration) error {
+	return s.Client.Set(context.Background(), key+":lock", 1, duration).Err()
+}
+
+func (s *RedisStore) LockedFor(key string) (time.Duration, error) {
+	ttl, err := s.Client.PTTL(context.Background(), key+":lock").Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s *RedisStore) Reset(key string) error {
+	return s.Client.Del(context.Background(), key).Err()
+}
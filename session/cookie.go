@@ -0,0 +1,295 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/go-tron/base-error"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/kataras/golog"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/core/memstore"
+	"github.com/kataras/iris/v12/sessions"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	ErrorCookieTooLarge = baseError.SystemFactory("104", "session cookie too large: {} bytes")
+)
+
+const (
+	cookieDataSuffix     = "_data"
+	maxCookieSize        = 4000
+	cookieSidsContextKey = "sessionCookieSids"
+)
+
+type cookiePayload struct {
+	Exp    int64                  `json:"e,omitempty"`
+	Values map[string]interface{} `json:"v"`
+}
+
+type cookieEntry struct {
+	store    *memstore.Store
+	requests int
+}
+
+// cookieDatabase holds the values of the sessions being served, they are loaded from the sealed cookie when the first request
+// of a session begins and dropped when its last one ends, the cookie is the only place they are kept.
+type cookieDatabase struct {
+	entries map[string]*cookieEntry
+	mu      sync.RWMutex
+}
+
+var _ sessions.Database = (*cookieDatabase)(nil)
+
+func newCookieDatabase() *cookieDatabase {
+	return &cookieDatabase{entries: make(map[string]*cookieEntry)}
+}
+
+func (db *cookieDatabase) SetLogger(*golog.Logger) {}
+
+func (db *cookieDatabase) Acquire(sid string, expires time.Duration) sessions.LifeTime {
+	return sessions.LifeTime{}
+}
+
+func (db *cookieDatabase) OnUpdateExpiration(string, time.Duration) error {
+	return nil
+}
+
+func (db *cookieDatabase) store(sid string) *memstore.Store {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if entry, ok := db.entries[sid]; ok {
+		return entry.store
+	}
+	return nil
+}
+
+// Set drops the values of a session which isn't being served, they couldn't be written to its cookie.
+func (db *cookieDatabase) Set(sid string, key string, value interface{}, _ time.Duration, immutable bool) error {
+	if store := db.store(sid); store != nil {
+		store.Save(key, value, immutable)
+	}
+	return nil
+}
+
+func (db *cookieDatabase) Get(sid string, key string) interface{} {
+	if store := db.store(sid); store != nil {
+		return store.Get(key)
+	}
+	return nil
+}
+
+func (db *cookieDatabase) Decode(sid string, key string, outPtr interface{}) error {
+	v := db.Get(sid, key)
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, outPtr)
+}
+
+func (db *cookieDatabase) Visit(sid string, cb func(key string, value interface{})) error {
+	if store := db.store(sid); store != nil {
+		store.Visit(cb)
+	}
+	return nil
+}
+
+func (db *cookieDatabase) Len(sid string) int {
+	if store := db.store(sid); store != nil {
+		return store.Len()
+	}
+	return 0
+}
+
+func (db *cookieDatabase) Delete(sid string, key string) bool {
+	if store := db.store(sid); store != nil {
+		return store.Remove(key)
+	}
+	return false
+}
+
+func (db *cookieDatabase) Clear(sid string) error {
+	if store := db.store(sid); store != nil {
+		store.Reset()
+	}
+	return nil
+}
+
+// Release empties a destroyed session, the entry itself goes when its requests end.
+func (db *cookieDatabase) Release(sid string) error {
+	db.mu.Lock()
+	if entry, ok := db.entries[sid]; ok {
+		entry.store.Reset()
+	}
+	db.mu.Unlock()
+	return nil
+}
+
+func (db *cookieDatabase) Close() error {
+	return nil
+}
+
+// begin loads the values carried by the request unless another request of the session is already being served.
+func (db *cookieDatabase) begin(sid string, values map[string]interface{}) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if entry, ok := db.entries[sid]; ok {
+		entry.requests++
+		return
+	}
+	store := new(memstore.Store)
+	for key, value := range values {
+		store.Set(key, value)
+	}
+	db.entries[sid] = &cookieEntry{store: store, requests: 1}
+}
+
+func (db *cookieDatabase) end(sid string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if entry, ok := db.entries[sid]; ok {
+		if entry.requests--; entry.requests <= 0 {
+			delete(db.entries, sid)
+		}
+	}
+}
+
+func (db *cookieDatabase) snapshot(sid string) map[string]interface{} {
+	values := make(map[string]interface{})
+	db.Visit(sid, func(key string, value interface{}) {
+		values[key] = value
+	})
+	return values
+}
+
+func (s *Sessions) dataCookieName() string {
+	return s.Cookie + cookieDataSuffix
+}
+
+func (s *Sessions) readDataCookie(ctx iris.Context, sid string) (*cookiePayload, bool) {
+	sealed := ctx.GetCookie(s.dataCookieName())
+	if sealed == "" {
+		return nil, false
+	}
+	data, err := s.sealer.Open(sealed, []byte(sid))
+	if err != nil {
+		return nil, false
+	}
+	payload := &cookiePayload{}
+	if err := json.Unmarshal(data, payload); err != nil {
+		return nil, false
+	}
+	if payload.Exp > 0 && payload.Exp < time.Now().Unix() {
+		return nil, false
+	}
+	return payload, true
+}
+
+// loadCookie loads the values carried by the request, the session is released by endCookies once the request ends.
+func (s *Sessions) loadCookie(ctx iris.Context, sess *sessions.Session) {
+	var values map[string]interface{}
+	if payload, ok := s.readDataCookie(ctx, sess.ID()); ok {
+		values = payload.Values
+	}
+	s.cookieStore.begin(sess.ID(), values)
+	sids, _ := ctx.Values().Get(cookieSidsContextKey).([]string)
+	ctx.Values().Set(cookieSidsContextKey, append(sids, sess.ID()))
+}
+
+// endCookies releases the sessions loaded during the request, a rotated session loads two of them.
+func (s *Sessions) endCookies(ctx iris.Context) {
+	sids, _ := ctx.Values().Get(cookieSidsContextKey).([]string)
+	for _, sid := range sids {
+		s.cookieStore.end(sid)
+	}
+}
+
+// dataCookie keeps the sealed values out of scripts, plain http and cross site requests whatever the session cookie options are.
+func dataCookie(secure bool) context.CookieOption {
+	return func(_ *context.Context, c *http.Cookie, op uint8) {
+		if op != context.OpCookieSet {
+			return
+		}
+		c.HttpOnly = true
+		c.Secure = secure
+		if c.SameSite != http.SameSiteStrictMode {
+			c.SameSite = http.SameSiteLaxMode
+		}
+	}
+}
+
+// cookieError renders the error in place of the buffered response, the client would otherwise see a success
+// while the changes of the session are lost.
+func (s *Sessions) cookieError(ctx iris.Context, err error) {
+	ctx.Application().Logger().Error("session cookie: ", err)
+	if rec, ok := ctx.IsRecording(); ok {
+		rec.ResetBody()
+	}
+	baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.StatusCode(iris.StatusInternalServerError)
+		ctx.WriteError(ctx.BaseError(err))
+	})(ctx)
+}
+
+// saveCookie writes the values back when they changed or half of the lifetime has passed, the response must still be buffered.
+func (s *Sessions) saveCookie(ctx iris.Context) {
+	sess, _ := ctx.Values().Get(sessionContextKey).(*sessions.Session)
+	if sess == nil {
+		ctx.RemoveCookie(s.dataCookieName(), s.CookieOptions...)
+		return
+	}
+	sid := sess.ID()
+	values := s.cookieStore.snapshot(sid)
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		ctx.Values().Set("error", err)
+		return
+	}
+	now := time.Now()
+	if old, ok := s.readDataCookie(ctx, sid); ok {
+		oldData, _ := json.Marshal(old.Values)
+		fresh := old.Exp == 0 || s.Expires <= 0 || time.Unix(old.Exp, 0).Sub(now) > s.Expires/2
+		if fresh && bytes.Equal(oldData, data) {
+			return
+		}
+	} else if len(values) == 0 {
+		return
+	}
+
+	payload := cookiePayload{Values: values}
+	if s.Expires > 0 {
+		payload.Exp = now.Add(s.Expires).Unix()
+	}
+	if data, err = json.Marshal(payload); err != nil {
+		s.cookieError(ctx, err)
+		return
+	}
+	sealed, err := s.sealer.Seal(data, []byte(sid))
+	if err != nil {
+		s.cookieError(ctx, err)
+		return
+	}
+	if len(sealed) > maxCookieSize {
+		s.cookieError(ctx, ErrorCookieTooLarge(len(sealed)))
+		return
+	}
+
+	cookie := &http.Cookie{
+		Name:  s.dataCookieName(),
+		Value: sealed,
+		Path:  "/",
+	}
+	if s.Expires > 0 {
+		cookie.MaxAge = int(s.Expires / time.Second)
+		cookie.Expires = now.Add(s.Expires)
+	}
+	ctx.SetCookie(cookie, s.cookieOptions([]context.CookieOption{dataCookie(ctx.Request().TLS != nil)})...)
+}
@@ -0,0 +1,81 @@
+package session
+
+import (
+	"bytes"
+	"github.com/kataras/iris/v12"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+var testKey = bytes.Repeat([]byte("k"), 32)
+
+func newCookieSessions(opts ...Option) *Sessions {
+	return New(append([]Option{WithCookie("sid"), WithCookieStore(true), WithEncryptionKeys(testKey)}, opts...)...)
+}
+
+func TestCookieStore(t *testing.T) {
+	s := newCookieSessions(WithHttpOnly(false), WithSameSite(http.SameSiteNoneMode))
+	e := newExpect(t, newApp(s))
+
+	resp := e.GET("/set").WithQuery("name", "a").Expect().Status(iris.StatusOK)
+	var data *http.Cookie
+	for _, c := range resp.Raw().Cookies() {
+		if c.Name == "sid_data" {
+			data = c
+		}
+	}
+	if data == nil {
+		t.Fatal("no data cookie")
+	}
+	if !data.HttpOnly || data.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("data cookie %+v", data)
+	}
+	e.GET("/get").Expect().Body().IsEqual("a")
+
+	s.cookieStore.mu.RLock()
+	held := len(s.cookieStore.entries)
+	s.cookieStore.mu.RUnlock()
+	if held != 0 {
+		t.Fatalf("%d sessions held after the requests", held)
+	}
+}
+
+func TestCookieStoreIsStateless(t *testing.T) {
+	e := newExpect(t, newApp(newCookieSessions()))
+	resp := e.GET("/set").WithQuery("name", "a").Expect()
+	sid := resp.Cookie("sid").Value().Raw()
+	data := resp.Cookie("sid_data").Value().Raw()
+
+	//another instance sharing the keys reads the values from the cookies alone
+	other := newExpect(t, newApp(newCookieSessions()))
+	other.GET("/get").WithCookie("sid", sid).WithCookie("sid_data", data).Expect().Body().IsEqual("a")
+	//the sealed values are bound to the session id
+	other.GET("/get").WithCookie("sid", sid+"x").WithCookie("sid_data", data).Expect().Body().IsEmpty()
+	other.GET("/get").WithCookie("sid", sid).WithCookie("sid_data", data[:len(data)-2]).Expect().Body().IsEmpty()
+}
+
+func TestCookieStoreRotate(t *testing.T) {
+	s := newCookieSessions()
+	e := newExpect(t, newApp(s))
+	oldId := e.GET("/set").WithQuery("name", "a").Expect().Body().Raw()
+	e.GET("/rotate").Expect().Status(iris.StatusOK).Body().NotEqual(oldId)
+	e.GET("/get").Expect().Body().IsEqual("a")
+	if len(s.cookieStore.entries) != 0 {
+		t.Fatal("sessions held after the requests")
+	}
+}
+
+func TestCookieTooLarge(t *testing.T) {
+	app := newApp(newCookieSessions())
+	app.Logger().SetLevel("disable")
+	e := newExpect(t, app)
+	resp := e.GET("/set").WithQuery("name", strings.Repeat("a", maxCookieSize)).Expect().
+		Status(iris.StatusInternalServerError)
+	resp.JSON().Object().Value("code").IsEqual("104")
+	for _, c := range resp.Raw().Cookies() {
+		if c.Name == "sid_data" {
+			t.Fatalf("data cookie %d bytes", len(c.Value))
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package session
+
+import (
+	"github.com/go-tron/config"
+	"github.com/kataras/iris/v12/sessions"
+	"github.com/kataras/iris/v12/sessions/sessiondb/boltdb"
+	sessionRedis "github.com/kataras/iris/v12/sessions/sessiondb/redis"
+	"github.com/redis/go-redis/v9"
+	"os"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+	BackendBoltDB = "boltdb"
+	BackendCookie = "cookie"
+)
+
+// NewRedisDatabase accepts any redis.UniversalClient, e.g. *redis.Client, *redis.ClusterClient or a sentinel failover client.
+func NewRedisDatabase(client redis.UniversalClient, prefix string) *sessionRedis.Database {
+	if client == nil {
+		panic("client 必须设置")
+	}
+	return sessionRedis.New(sessionRedis.Config{
+		Prefix: prefix,
+		Driver: sessionRedis.GoRedis().SetClient(client),
+	})
+}
+
+func NewBoltDatabase(path string) *boltdb.Database {
+	db, err := boltdb.New(path, os.FileMode(0750))
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+func NewRedisClientWithConfig(c *config.Config) redis.UniversalClient {
+	return redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:      c.GetStringSlice("session.redis.addrs"),
+		MasterName: c.GetString("session.redis.masterName"),
+		Username:   c.GetString("session.redis.username"),
+		Password:   c.GetString("session.redis.password"),
+		DB:         c.GetInt("session.redis.database"),
+	})
+}
+
+// NewDatabaseWithConfig returns nil for the memory and cookie backends.
+func NewDatabaseWithConfig(c *config.Config, client redis.UniversalClient) sessions.Database {
+	backend := c.GetString("session.backend")
+	if backend == "" {
+		if client != nil || c.IsSet("session.redis.addrs") {
+			backend = BackendRedis
+		} else {
+			backend = BackendMemory
+		}
+	}
+
+	switch backend {
+	case BackendMemory, BackendCookie:
+		return nil
+	case BackendRedis:
+		if client == nil {
+			client = NewRedisClientWithConfig(c)
+		}
+		prefix := c.GetString("session.redis.prefix")
+		if prefix == "" {
+			prefix = c.GetString("application.name") + "-sess:"
+		}
+		return NewRedisDatabase(client, prefix)
+	case BackendBoltDB:
+		path := c.GetString("session.boltdb.path")
+		if path == "" {
+			path = "./sessions/" + c.GetString("application.name") + ".db"
+		}
+		return NewBoltDatabase(path)
+	default:
+		panic("session.backend 不支持:" + backend)
+	}
+}
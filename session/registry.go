@@ -0,0 +1,406 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-tron/base-error"
+	"github.com/go-tron/config"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/kataras/iris/v12"
+	"github.com/redis/go-redis/v9"
+	"sort"
+	"time"
+)
+
+var (
+	ErrorTooManySessions = baseError.Factory("4370", "too many sessions, max {}")
+)
+
+const (
+	userIdKey        = "_userId"
+	registryTouchKey = "_registryTouchedAt"
+)
+
+const maxRegisterRetries = 10
+
+const (
+	PolicyEvictOldest = "evictOldest"
+	PolicyReject      = "reject"
+)
+
+type RegistryOption func(*RegistryConfig)
+
+func defaultRegistryConfig() *RegistryConfig {
+	return &RegistryConfig{
+		Prefix:        "sess-registry:",
+		Policy:        PolicyEvictOldest,
+		TouchInterval: time.Minute,
+	}
+}
+
+func WithRegistryPrefix(val string) RegistryOption {
+	return func(opts *RegistryConfig) {
+		opts.Prefix = val
+	}
+}
+
+// WithMaxSessions limits the concurrent sessions of a user, 0 means unlimited.
+func WithMaxSessions(val int) RegistryOption {
+	return func(opts *RegistryConfig) {
+		opts.MaxSessions = val
+	}
+}
+
+// WithPolicy decides what happens when MaxSessions is reached, PolicyEvictOldest or PolicyReject.
+func WithPolicy(val string) RegistryOption {
+	return func(opts *RegistryConfig) {
+		opts.Policy = val
+	}
+}
+
+// WithTTL sets how long a device entry is kept after its last activity, defaults to the session Expires.
+func WithTTL(val time.Duration) RegistryOption {
+	return func(opts *RegistryConfig) {
+		opts.TTL = val
+	}
+}
+func WithTouchInterval(val time.Duration) RegistryOption {
+	return func(opts *RegistryConfig) {
+		opts.TouchInterval = val
+	}
+}
+
+type RegistryConfig struct {
+	Prefix        string
+	MaxSessions   int
+	Policy        string
+	TTL           time.Duration
+	TouchInterval time.Duration
+}
+
+type Device struct {
+	SessionId  string `json:"sessionId"`
+	UserId     string `json:"userId"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"userAgent"`
+	CreatedAt  int64  `json:"createdAt"`
+	LastSeenAt int64  `json:"lastSeenAt"`
+}
+
+// Registry indexes sessions by user in redis so that they can be listed and revoked from any instance.
+type Registry struct {
+	*RegistryConfig
+	Client   redis.UniversalClient
+	Sessions *Sessions
+}
+
+func NewRegistry(client redis.UniversalClient, sessions *Sessions, opts ...RegistryOption) *Registry {
+	if client == nil {
+		panic("client 必须设置")
+	}
+	if sessions == nil {
+		panic("sessions 必须设置")
+	}
+	config := defaultRegistryConfig()
+	for _, apply := range opts {
+		apply(config)
+	}
+	if config.TTL == 0 {
+		config.TTL = sessions.Expires
+	}
+	if config.TTL <= 0 {
+		panic("TTL 必须设置")
+	}
+	if config.Policy != PolicyEvictOldest && config.Policy != PolicyReject {
+		panic("policy 不支持:" + config.Policy)
+	}
+	return &Registry{
+		RegistryConfig: config,
+		Client:         client,
+		Sessions:       sessions,
+	}
+}
+
+// NewRegistryWithConfig reads the "session.registry.*" keys.
+func NewRegistryWithConfig(c *config.Config, client redis.UniversalClient, sessions *Sessions, opts ...RegistryOption) *Registry {
+	prefix := c.GetString("session.registry.prefix")
+	if prefix == "" {
+		prefix = c.GetString("application.name") + "-sess-registry:"
+	}
+	options := []RegistryOption{
+		WithRegistryPrefix(prefix),
+		WithMaxSessions(c.GetInt("session.registry.maxSessions")),
+		WithTTL(c.GetDuration("session.registry.ttl")),
+	}
+	if c.IsSet("session.registry.policy") {
+		options = append(options, WithPolicy(c.GetString("session.registry.policy")))
+	}
+	return NewRegistry(client, sessions, append(options, opts...)...)
+}
+
+// the hash tag keeps the keys of a user on one cluster slot, Register updates them in a transaction
+func (r *Registry) userKey(userId string) string {
+	return r.Prefix + "{" + userId + "}"
+}
+
+func (r *Registry) deviceKey(userId string, sid string) string {
+	return r.Prefix + "{" + userId + "}:" + sid
+}
+
+// Register binds the current session to the user, call it after login (and after RotateSession).
+// The limit is checked and the evictions are applied in one transaction, concurrent logins can't exceed MaxSessions.
+func (r *Registry) Register(ctx *baseContext.Context, userId string) error {
+	sess := ctx.GetSession()
+	if sess == nil {
+		return ErrNotStarted
+	}
+	sid := sess.ID()
+	registeredAt := time.Now()
+	now := registeredAt.Unix()
+	device := &Device{
+		SessionId:  sid,
+		UserId:     userId,
+		IP:         ctx.GetIP(),
+		UserAgent:  ctx.GetHeader("User-Agent"),
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	data, err := json.Marshal(device)
+	if err != nil {
+		return err
+	}
+
+	c := context.Background()
+	userKey := r.userKey(userId)
+	var evicted []string
+	register := func(tx *redis.Tx) error {
+		devices, stale, err := r.list(c, tx, userId)
+		if err != nil {
+			return err
+		}
+		evicted = nil
+		if r.MaxSessions > 0 {
+			var others []*Device
+			for _, device := range devices {
+				if device.SessionId != sid {
+					others = append(others, device)
+				}
+			}
+			for len(others) >= r.MaxSessions {
+				if r.Policy == PolicyReject {
+					return ErrorTooManySessions(r.MaxSessions)
+				}
+				evicted = append(evicted, others[0].SessionId)
+				others = others[1:]
+			}
+		}
+		_, err = tx.TxPipelined(c, func(pipe redis.Pipeliner) error {
+			for _, sid := range append(stale, evicted...) {
+				pipe.Del(c, r.deviceKey(userId, sid))
+				pipe.ZRem(c, userKey, sid)
+			}
+			pipe.Set(c, r.deviceKey(userId, sid), data, r.TTL)
+			//microseconds keep the order of logins within the same second
+			pipe.ZAdd(c, userKey, redis.Z{Score: float64(registeredAt.UnixMicro()), Member: sid})
+			pipe.Expire(c, userKey, r.TTL)
+			return nil
+		})
+		return err
+	}
+	for i := 0; ; i++ {
+		err = r.Client.Watch(c, register, userKey)
+		if err != redis.TxFailedErr || i == maxRegisterRetries {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, sid := range evicted {
+		r.Sessions.DestroyByID(sid)
+	}
+	sess.Set(userIdKey, userId)
+	sess.Set(registryTouchKey, now)
+	return nil
+}
+
+// touch only updates a device which is still registered, a revoked one isn't brought back.
+func (r *Registry) touch(device *Device) error {
+	data, err := json.Marshal(device)
+	if err != nil {
+		return err
+	}
+	c := context.Background()
+	userKey := r.userKey(device.UserId)
+	_, err = r.Client.Pipelined(c, func(pipe redis.Pipeliner) error {
+		pipe.SetXX(c, r.deviceKey(device.UserId, device.SessionId), data, r.TTL)
+		pipe.Expire(c, userKey, r.TTL)
+		return nil
+	})
+	return err
+}
+
+// Touch refreshes the last activity of the current session at most once per TouchInterval.
+func (r *Registry) Touch(ctx *baseContext.Context) error {
+	sess := ctx.GetSession()
+	if sess == nil {
+		return nil
+	}
+	userId := sess.GetString(userIdKey)
+	if userId == "" {
+		return nil
+	}
+	now := time.Now().Unix()
+	if now-sess.GetInt64Default(registryTouchKey, 0) < int64(r.TouchInterval/time.Second) {
+		return nil
+	}
+	sess.Set(registryTouchKey, now)
+
+	data, err := r.Client.Get(context.Background(), r.deviceKey(userId, sess.ID())).Bytes()
+	if err == redis.Nil {
+		//revoked from another instance
+		return nil
+	} else if err != nil {
+		return err
+	}
+	device := &Device{}
+	if err := json.Unmarshal(data, device); err != nil {
+		return err
+	}
+	device.IP = ctx.GetIP()
+	device.LastSeenAt = now
+	return r.touch(device)
+}
+
+func (r *Registry) list(c context.Context, client redis.Cmdable, userId string) ([]*Device, []string, error) {
+	sids, err := client.ZRange(c, r.userKey(userId), 0, -1).Result()
+	if err != nil || len(sids) == 0 {
+		return nil, nil, err
+	}
+	cmds := make([]*redis.StringCmd, len(sids))
+	if _, err := client.Pipelined(c, func(pipe redis.Pipeliner) error {
+		for i, sid := range sids {
+			cmds[i] = pipe.Get(c, r.deviceKey(userId, sid))
+		}
+		return nil
+	}); err != nil && err != redis.Nil {
+		return nil, nil, err
+	}
+
+	var devices []*Device
+	var stale []string
+	for i, cmd := range cmds {
+		data, err := cmd.Bytes()
+		if err == redis.Nil {
+			stale = append(stale, sids[i])
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		device := &Device{}
+		if err := json.Unmarshal(data, device); err != nil {
+			stale = append(stale, sids[i])
+			continue
+		}
+		devices = append(devices, device)
+	}
+	sort.SliceStable(devices, func(i, j int) bool {
+		return devices[i].CreatedAt < devices[j].CreatedAt
+	})
+	return devices, stale, nil
+}
+
+// List returns the active sessions of the user ordered by creation, stale entries are pruned.
+func (r *Registry) List(userId string) ([]*Device, error) {
+	c := context.Background()
+	devices, stale, err := r.list(c, r.Client, userId)
+	if err != nil {
+		return nil, err
+	}
+	if len(stale) > 0 {
+		members := make([]interface{}, len(stale))
+		for i, sid := range stale {
+			members[i] = sid
+		}
+		if err := r.Client.ZRem(c, r.userKey(userId), members...).Err(); err != nil {
+			return nil, err
+		}
+	}
+	return devices, nil
+}
+
+// Revoke destroys one session of the user, wherever it was created.
+func (r *Registry) Revoke(userId string, sid string) error {
+	r.Sessions.DestroyByID(sid)
+	c := context.Background()
+	_, err := r.Client.Pipelined(c, func(pipe redis.Pipeliner) error {
+		pipe.Del(c, r.deviceKey(userId, sid))
+		pipe.ZRem(c, r.userKey(userId), sid)
+		return nil
+	})
+	return err
+}
+
+// RevokeAll logs the user out everywhere.
+func (r *Registry) RevokeAll(userId string) error {
+	c := context.Background()
+	userKey := r.userKey(userId)
+	sids, err := r.Client.ZRange(c, userKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	for _, sid := range sids {
+		r.Sessions.DestroyByID(sid)
+	}
+	_, err = r.Client.Pipelined(c, func(pipe redis.Pipeliner) error {
+		for _, sid := range sids {
+			pipe.Del(c, r.deviceKey(userId, sid))
+		}
+		pipe.Del(c, userKey)
+		return nil
+	})
+	return err
+}
+
+// Unregister removes the current session from the registry, call it on logout.
+func (r *Registry) Unregister(ctx *baseContext.Context) error {
+	sess := ctx.GetSession()
+	if sess == nil {
+		return ErrNotStarted
+	}
+	userId := sess.GetString(userIdKey)
+	if userId == "" {
+		return nil
+	}
+	sess.Delete(userIdKey)
+	sess.Delete(registryTouchKey)
+	c := context.Background()
+	_, err := r.Client.Pipelined(c, func(pipe redis.Pipeliner) error {
+		pipe.Del(c, r.deviceKey(userId, sess.ID()))
+		pipe.ZRem(c, r.userKey(userId), sess.ID())
+		return nil
+	})
+	return err
+}
+
+// GetUserId returns the user bound to the current session by Register.
+func GetUserId(ctx *baseContext.Context) string {
+	if sess := ctx.GetSession(); sess != nil {
+		return sess.GetString(userIdKey)
+	}
+	return ""
+}
+
+func (r *Registry) Context(ctx *baseContext.Context) {
+	//the request doesn't fail for a missed touch, the error is logged with it
+	if err := r.Touch(ctx); err != nil && ctx.Logger != nil {
+		ctx.AddLogField("sessionRegistryError", err.Error())
+	}
+	ctx.Next()
+}
+
+// Handler keeps the last activity up to date, register it after the sessions Handler.
+func (r *Registry) Handler() iris.Handler {
+	return baseContext.Handler(r.Context)
+}
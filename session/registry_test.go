@@ -0,0 +1,130 @@
+package session
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"testing"
+)
+
+func newRegistry(t *testing.T, opts ...RegistryOption) (*Registry, *iris.Application) {
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+	s := New(WithCookie("sid"))
+	r := NewRegistry(client, s, opts...)
+	app := newApp(s, r.Handler())
+	app.Get("/login", baseContext.Handler(func(ctx *baseContext.Context) {
+		if err := r.Register(ctx, ctx.URLParam("user")); err != nil {
+			ctx.Error(err)
+			return
+		}
+		ctx.WriteString(ctx.GetSession().ID())
+	}))
+	app.Get("/user", baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.WriteString(GetUserId(ctx))
+	}))
+	app.Get("/logout", baseContext.Handler(func(ctx *baseContext.Context) {
+		if err := r.Unregister(ctx); err != nil {
+			ctx.Error(err)
+		}
+	}))
+	return r, app
+}
+
+func login(t *testing.T, app *iris.Application, user string) (*httptest.Expect, string) {
+	e := newExpect(t, app)
+	return e, e.GET("/login").WithQuery("user", user).Expect().Status(iris.StatusOK).Body().Raw()
+}
+
+func TestRegisterEvictOldest(t *testing.T) {
+	r, app := newRegistry(t, WithMaxSessions(2))
+	first, firstId := login(t, app, "u1")
+	_, secondId := login(t, app, "u1")
+	_, thirdId := login(t, app, "u1")
+
+	devices, err := r.List("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 || devices[0].SessionId == firstId || devices[1].SessionId == firstId {
+		t.Fatalf("devices %+v", devices)
+	}
+	for _, device := range devices {
+		if device.SessionId != secondId && device.SessionId != thirdId {
+			t.Fatalf("devices %+v", devices)
+		}
+	}
+	first.GET("/user").Expect().Body().IsEmpty()
+}
+
+func TestRegisterReject(t *testing.T) {
+	_, app := newRegistry(t, WithMaxSessions(1), WithPolicy(PolicyReject))
+	e, _ := login(t, app, "u1")
+	//registering the same session again isn't a new one
+	e.GET("/login").WithQuery("user", "u1").Expect().Status(iris.StatusOK)
+	newExpect(t, app).GET("/login").WithQuery("user", "u1").Expect().
+		JSON().Object().Value("code").IsEqual("4370")
+}
+
+func TestRegisterConcurrent(t *testing.T) {
+	r, app := newRegistry(t, WithMaxSessions(2))
+	//httptest.New builds the app, the clients are created up front
+	clients := make([]*httptest.Expect, 8)
+	for i := range clients {
+		clients[i] = newExpect(t, app)
+	}
+	var wg sync.WaitGroup
+	for _, e := range clients {
+		wg.Add(1)
+		go func(e *httptest.Expect) {
+			defer wg.Done()
+			e.GET("/login").WithQuery("user", "u1").Expect().Status(iris.StatusOK)
+		}(e)
+	}
+	wg.Wait()
+	devices, err := r.List("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("devices %d", len(devices))
+	}
+}
+
+func TestRevokeAndUnregister(t *testing.T) {
+	r, app := newRegistry(t)
+	e, sid := login(t, app, "u1")
+	other, otherId := login(t, app, "u1")
+
+	e.GET("/user").Expect().Body().IsEqual("u1")
+	if err := r.Revoke("u1", sid); err != nil {
+		t.Fatal(err)
+	}
+	e.GET("/user").Expect().Body().IsEmpty()
+
+	other.GET("/logout").Expect().Status(iris.StatusOK)
+	other.GET("/user").Expect().Body().IsEmpty()
+	devices, err := r.List("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 0 {
+		t.Fatalf("devices %+v, %s", devices, otherId)
+	}
+}
+
+func TestRevokeAll(t *testing.T) {
+	r, app := newRegistry(t)
+	e, _ := login(t, app, "u1")
+	login(t, app, "u1")
+	if err := r.RevokeAll("u1"); err != nil {
+		t.Fatal(err)
+	}
+	e.GET("/user").Expect().Body().IsEmpty()
+	if devices, _ := r.List("u1"); len(devices) != 0 {
+		t.Fatalf("devices %+v", devices)
+	}
+}
@@ -0,0 +1,100 @@
+package session
+
+import (
+	"errors"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/sessions"
+	"time"
+)
+
+var ErrNotStarted = errors.New("session not started")
+
+const (
+	createdAtKey  = "_createdAt"
+	lastSeenAtKey = "_lastSeenAt"
+)
+
+func (s *Sessions) expired(sess *sessions.Session) bool {
+	now := time.Now().Unix()
+	if s.AbsoluteTimeout > 0 {
+		if createdAt := sess.GetInt64Default(createdAtKey, 0); createdAt > 0 && now-createdAt > int64(s.AbsoluteTimeout/time.Second) {
+			return true
+		}
+	}
+	if s.IdleTimeout > 0 {
+		if lastSeenAt := sess.GetInt64Default(lastSeenAtKey, 0); lastSeenAt > 0 && now-lastSeenAt > int64(s.IdleTimeout/time.Second) {
+			return true
+		}
+	}
+	return false
+}
+
+// touch only stamps sessions which already hold values, anonymous sessions aren't written to the store for the timeouts.
+func (s *Sessions) touch(sess *sessions.Session) {
+	if sess.Len() == 0 {
+		return
+	}
+	s.stamp(sess)
+}
+
+func (s *Sessions) stamp(sess *sessions.Session) {
+	now := time.Now().Unix()
+	if s.AbsoluteTimeout > 0 && sess.GetInt64Default(createdAtKey, 0) == 0 {
+		sess.Set(createdAtKey, now)
+	}
+	if s.IdleTimeout > 0 {
+		sess.Set(lastSeenAtKey, now)
+	}
+}
+
+// DestroyByID removes the session from this process and from the database, so it also works for sessions served by other replicas.
+func (s *Sessions) DestroyByID(sid string) {
+	s.Sessions.DestroyByID(sid)
+	if s.Database != nil {
+		s.Database.Release(sid)
+	}
+}
+
+// removeRequestCookie drops the session cookie from the request so that the following Start issues a new id.
+func (s *Sessions) removeRequestCookie(ctx iris.Context) {
+	r := ctx.Request()
+	cookies := r.Cookies()
+	r.Header.Del("Cookie")
+	for _, c := range cookies {
+		if c.Name != s.Cookie {
+			r.AddCookie(c)
+		}
+	}
+}
+
+// Rotate issues a new session id for the current session, the old id is invalidated in the store.
+// Values and flashes are carried over except the clearKeys, use it on login and privilege changes against session fixation.
+func (s *Sessions) Rotate(ctx iris.Context, clearKeys ...string) (*sessions.Session, error) {
+	old := sessions.Get(ctx)
+	if old == nil {
+		return nil, ErrNotStarted
+	}
+
+	values := old.GetAll()
+	flashes := old.GetFlashes()
+	delete(values, createdAtKey)
+	delete(values, lastSeenAtKey)
+	for _, key := range clearKeys {
+		delete(values, key)
+		delete(flashes, key)
+	}
+
+	s.DestroyByID(old.ID())
+	s.removeRequestCookie(ctx)
+
+	sess := s.Start(ctx)
+	for key, value := range values {
+		sess.Set(key, value)
+	}
+	for key, value := range flashes {
+		sess.SetFlash(key, value)
+	}
+	s.stamp(sess)
+	ctx.Values().Set(sessionContextKey, sess)
+	return sess, nil
+}
@@ -0,0 +1,108 @@
+package session
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/sessions"
+	"github.com/redis/go-redis/v9"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotate(t *testing.T) {
+	db := NewBoltDatabase(filepath.Join(t.TempDir(), "sessions.db"))
+	defer db.Close()
+	s := New(WithCookie("sid"), WithDatabase(db), WithAbsoluteTimeout(time.Hour))
+	app := newApp(s)
+	app.Get("/secret", baseContext.Handler(func(ctx *baseContext.Context) {
+		sessions.Get(ctx.Context).Set("secret", "s")
+	}))
+	e := newExpect(t, app)
+
+	oldId := e.GET("/set").WithQuery("name", "a").Expect().Body().Raw()
+	e.GET("/secret").Expect().Status(iris.StatusOK)
+
+	newId := e.GET("/rotate").Expect().Status(iris.StatusOK).Body().NotEqual(oldId).Raw()
+	e.GET("/id").Expect().Body().IsEqual(newId)
+	e.GET("/get").Expect().Body().IsEqual("a")
+	if db.Len(oldId) != 0 {
+		t.Fatal("the old session is still stored")
+	}
+	if v := db.Get(newId, "secret"); v != nil {
+		t.Fatalf("secret carried over: %v", v)
+	}
+	if v := db.Get(newId, createdAtKey); v == nil {
+		t.Fatal("the rotated session isn't stamped")
+	}
+}
+
+func TestDestroyByIDReleasesOtherReplicas(t *testing.T) {
+	db := NewBoltDatabase(filepath.Join(t.TempDir(), "sessions.db"))
+	defer db.Close()
+	e := newExpect(t, newApp(New(WithCookie("sid"), WithDatabase(db))))
+	sid := e.GET("/set").WithQuery("name", "a").Expect().Body().Raw()
+
+	//the second replica never served the session, it only shares the database
+	New(WithCookie("sid"), WithDatabase(db)).DestroyByID(sid)
+	if db.Len(sid) != 0 {
+		t.Fatal("the session is still stored")
+	}
+}
+
+func TestTouchSkipsAnonymousSessions(t *testing.T) {
+	db := NewBoltDatabase(filepath.Join(t.TempDir(), "sessions.db"))
+	defer db.Close()
+	e := newExpect(t, newApp(New(WithCookie("sid"), WithDatabase(db), WithIdleTimeout(time.Hour), WithAbsoluteTimeout(time.Hour))))
+
+	sid := e.GET("/id").Expect().Body().Raw()
+	e.GET("/id").Expect().Body().IsEqual(sid)
+	if n := db.Len(sid); n != 0 {
+		t.Fatalf("anonymous session holds %d values", n)
+	}
+
+	e.GET("/set").WithQuery("name", "a").Expect()
+	e.GET("/get").Expect().Body().IsEqual("a")
+	if db.Get(sid, lastSeenAtKey) == nil || db.Get(sid, createdAtKey) == nil {
+		t.Fatal("the session isn't stamped")
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	s := New(WithCookie("sid"), WithIdleTimeout(time.Minute))
+	app := newApp(s)
+	app.Get("/idle", baseContext.Handler(func(ctx *baseContext.Context) {
+		sessions.Get(ctx.Context).Set(lastSeenAtKey, time.Now().Add(-time.Hour).Unix())
+	}))
+	e := newExpect(t, app)
+
+	sid := e.GET("/set").WithQuery("name", "a").Expect().Body().Raw()
+	e.GET("/idle").Expect().Status(iris.StatusOK)
+	e.GET("/get").Expect().Body().IsEmpty()
+	e.GET("/id").Expect().Body().NotEqual(sid)
+}
+
+func TestRotateWithConfig(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	s := NewSessionsWithConfig(newConfig(map[string]interface{}{
+		"application.name":        "app",
+		"session.cookieName":      "sid",
+		"session.absoluteTimeout": "1h",
+	}), client)
+	app := newApp(s)
+	app.Get("/secret", baseContext.Handler(func(ctx *baseContext.Context) {
+		sessions.Get(ctx.Context).Set("secret", "s")
+	}))
+	e := newExpect(t, app)
+
+	oldId := e.GET("/set").WithQuery("name", "a").Expect().Body().Raw()
+	e.GET("/secret").Expect().Status(iris.StatusOK)
+	newId := e.GET("/rotate").Expect().Status(iris.StatusOK).Body().NotEqual(oldId).Raw()
+	e.GET("/get").Expect().Body().IsEqual("a")
+	if keys := mr.Keys(); len(keys) == 0 || mr.Exists("app-sess:"+oldId) || !mr.Exists("app-sess:"+newId) {
+		t.Fatalf("redis keys %v", keys)
+	}
+}
@@ -0,0 +1,202 @@
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"github.com/kataras/golog"
+	"github.com/kataras/iris/v12/sessions"
+	"io"
+	"time"
+)
+
+var (
+	ErrInvalidKey    = errors.New("encryption key must be 16, 24 or 32 bytes")
+	ErrInvalidSealed = errors.New("invalid sealed value")
+)
+
+const (
+	sealVersion = 1
+	keyIdSize   = 4
+)
+
+type sealKey struct {
+	id   []byte
+	aead cipher.AEAD
+}
+
+// Sealer encrypts and authenticates with AES-GCM, the first key seals and every key opens, so keys can be rotated by prepending a new one.
+type Sealer struct {
+	keys []sealKey
+}
+
+func NewSealer(keys ...[]byte) (*Sealer, error) {
+	if len(keys) == 0 {
+		return nil, ErrInvalidKey
+	}
+	s := &Sealer{}
+	for _, key := range keys {
+		switch len(key) {
+		case 16, 24, 32:
+		default:
+			return nil, ErrInvalidKey
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(key)
+		s.keys = append(s.keys, sealKey{id: sum[:keyIdSize], aead: aead})
+	}
+	return s, nil
+}
+
+// ParseKeys decodes base64 keys, as they are written in the config.
+func ParseKeys(values ...string) ([][]byte, error) {
+	keys := make([][]byte, 0, len(values))
+	for _, v := range values {
+		key, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Seal returns version|keyId|nonce|ciphertext encoded in base64url, additionalData binds the value to its context, e.g. the session id.
+func (s *Sealer) Seal(plaintext []byte, additionalData []byte) (string, error) {
+	key := s.keys[0]
+	size := key.aead.NonceSize()
+	out := make([]byte, 1+keyIdSize+size, 1+keyIdSize+size+len(plaintext)+key.aead.Overhead())
+	out[0] = sealVersion
+	copy(out[1:], key.id)
+	nonce := out[1+keyIdSize:]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	out = key.aead.Seal(out, nonce, plaintext, additionalData)
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+func (s *Sealer) Open(sealed string, additionalData []byte) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil || len(data) < 1+keyIdSize || data[0] != sealVersion {
+		return nil, ErrInvalidSealed
+	}
+	id := data[1 : 1+keyIdSize]
+	for _, key := range s.keys {
+		if string(key.id) != string(id) {
+			continue
+		}
+		size := key.aead.NonceSize()
+		if len(data) < 1+keyIdSize+size {
+			return nil, ErrInvalidSealed
+		}
+		nonce := data[1+keyIdSize : 1+keyIdSize+size]
+		plaintext, err := key.aead.Open(nil, nonce, data[1+keyIdSize+size:], additionalData)
+		if err != nil {
+			return nil, ErrInvalidSealed
+		}
+		return plaintext, nil
+	}
+	return nil, ErrInvalidSealed
+}
+
+// SealedDatabase encrypts every value before it reaches the wrapped database, values are bound to their session id and key.
+// Values which can't be opened (tampered, unknown key or written before encryption was enabled) read as missing.
+type SealedDatabase struct {
+	sessions.Database
+	Sealer *Sealer
+	logger *golog.Logger
+}
+
+func NewSealedDatabase(db sessions.Database, sealer *Sealer) *SealedDatabase {
+	if db == nil {
+		panic("db 必须设置")
+	}
+	if sealer == nil {
+		panic("sealer 必须设置")
+	}
+	return &SealedDatabase{
+		Database: db,
+		Sealer:   sealer,
+	}
+}
+
+func sealedData(sid string, key string) []byte {
+	return []byte(sid + "\x00" + key)
+}
+
+func (db *SealedDatabase) SetLogger(logger *golog.Logger) {
+	db.logger = logger
+	db.Database.SetLogger(logger)
+}
+
+func (db *SealedDatabase) Set(sid string, key string, value interface{}, ttl time.Duration, immutable bool) error {
+	data, err := sessions.DefaultTranscoder.Marshal(value)
+	if err != nil {
+		return err
+	}
+	sealed, err := db.Sealer.Seal(data, sealedData(sid, key))
+	if err != nil {
+		return err
+	}
+	return db.Database.Set(sid, key, sealed, ttl, immutable)
+}
+
+func (db *SealedDatabase) open(sid string, key string, value interface{}) ([]byte, bool) {
+	sealed, ok := value.(string)
+	if !ok {
+		return nil, false
+	}
+	data, err := db.Sealer.Open(sealed, sealedData(sid, key))
+	if err != nil {
+		if db.logger != nil {
+			db.logger.Debugf("unable to open value of key: '%s%s': %v", sid, key, err)
+		}
+		return nil, false
+	}
+	return data, true
+}
+
+func (db *SealedDatabase) Get(sid string, key string) interface{} {
+	data, ok := db.open(sid, key, db.Database.Get(sid, key))
+	if !ok {
+		return nil
+	}
+	var value interface{}
+	if err := sessions.DefaultTranscoder.Unmarshal(data, &value); err != nil {
+		return nil
+	}
+	return value
+}
+
+func (db *SealedDatabase) Decode(sid string, key string, outPtr interface{}) error {
+	data, ok := db.open(sid, key, db.Database.Get(sid, key))
+	if !ok {
+		return nil
+	}
+	return sessions.DefaultTranscoder.Unmarshal(data, outPtr)
+}
+
+func (db *SealedDatabase) Visit(sid string, cb func(key string, value interface{})) error {
+	return db.Database.Visit(sid, func(key string, value interface{}) {
+		data, ok := db.open(sid, key, value)
+		if !ok {
+			return
+		}
+		var v interface{}
+		if err := sessions.DefaultTranscoder.Unmarshal(data, &v); err != nil {
+			return
+		}
+		cb(key, v)
+	})
+}
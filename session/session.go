@@ -2,23 +2,338 @@ package session
 
 import (
 	"github.com/go-tron/config"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/context"
 	"github.com/kataras/iris/v12/sessions"
-	sessionRedis "github.com/kataras/iris/v12/sessions/sessiondb/redis"
 	"github.com/redis/go-redis/v9"
+	"net/http"
+	"strings"
+	"time"
 )
 
-func NewSessionsWithConfig(c *config.Config, client *redis.Client) *sessions.Sessions {
-	db := sessionRedis.New(sessionRedis.Config{
-		Prefix: c.GetString("application.name") + "-sess:",
-		Driver: sessionRedis.GoRedis().SetClient(client),
-	})
-	sess := sessions.New(sessions.Config{
-		Cookie:                      c.GetString("session.cookieName"),
+const (
+	sessionContextKey = "iris.session"
+	managerContextKey = "sessionManager"
+)
+
+type Option func(*Config)
+
+func defaultConfig() *Config {
+	return &Config{
+		Cookie:                      sessions.DefaultCookieName,
+		Expires:                     2 * time.Hour,
 		CookieSecureTLS:             true,
 		AllowReclaim:                true,
-		Expires:                     c.GetDuration("session.maxAge"),
 		DisableSubdomainPersistence: true,
+		HttpOnly:                    true,
+	}
+}
+
+func WithCookie(val string) Option {
+	return func(opts *Config) {
+		opts.Cookie = val
+	}
+}
+func WithExpires(val time.Duration) Option {
+	return func(opts *Config) {
+		opts.Expires = val
+	}
+}
+func WithCookieSecureTLS(val bool) Option {
+	return func(opts *Config) {
+		opts.CookieSecureTLS = val
+	}
+}
+func WithAllowReclaim(val bool) Option {
+	return func(opts *Config) {
+		opts.AllowReclaim = val
+	}
+}
+func WithDisableSubdomainPersistence(val bool) Option {
+	return func(opts *Config) {
+		opts.DisableSubdomainPersistence = val
+	}
+}
+func WithSameSite(val http.SameSite) Option {
+	return func(opts *Config) {
+		opts.SameSite = val
+	}
+}
+func WithDomain(val string) Option {
+	return func(opts *Config) {
+		opts.Domain = val
+	}
+}
+func WithHttpOnly(val bool) Option {
+	return func(opts *Config) {
+		opts.HttpOnly = val
+	}
+}
+func WithEncoding(val context.SecureCookie) Option {
+	return func(opts *Config) {
+		opts.Encoding = val
+	}
+}
+func WithSessionIDGenerator(val func(ctx iris.Context) string) Option {
+	return func(opts *Config) {
+		opts.SessionIDGenerator = val
+	}
+}
+
+// WithIdleTimeout destroys sessions which haven't been used for the given duration.
+func WithIdleTimeout(val time.Duration) Option {
+	return func(opts *Config) {
+		opts.IdleTimeout = val
+	}
+}
+
+// WithAbsoluteTimeout destroys sessions older than the given duration regardless of activity.
+func WithAbsoluteTimeout(val time.Duration) Option {
+	return func(opts *Config) {
+		opts.AbsoluteTimeout = val
+	}
+}
+
+// WithDatabase sets the backend, without it sessions are kept in memory.
+func WithDatabase(val sessions.Database) Option {
+	return func(opts *Config) {
+		opts.Database = val
+	}
+}
+
+// WithEncryptionKeys seals the values stored in the database with AES-GCM, the first key encrypts and all of them decrypt.
+func WithEncryptionKeys(keys ...[]byte) Option {
+	return func(opts *Config) {
+		opts.EncryptionKeys = keys
+	}
+}
+
+// WithCookieStore keeps the values in a sealed cookie instead of a database, requires the encryption keys.
+func WithCookieStore(val bool) Option {
+	return func(opts *Config) {
+		opts.CookieStore = val
+	}
+}
+
+type Config struct {
+	Cookie                      string
+	Expires                     time.Duration
+	CookieSecureTLS             bool
+	AllowReclaim                bool
+	DisableSubdomainPersistence bool
+	SameSite                    http.SameSite
+	Domain                      string
+	HttpOnly                    bool
+	Encoding                    context.SecureCookie
+	SessionIDGenerator          func(ctx iris.Context) string
+	IdleTimeout                 time.Duration
+	AbsoluteTimeout             time.Duration
+	Database                    sessions.Database
+	EncryptionKeys              [][]byte
+	CookieStore                 bool
+}
+
+type Sessions struct {
+	*sessions.Sessions
+	*Config
+	CookieOptions []context.CookieOption
+	sealer        *Sealer
+	cookieStore   *cookieDatabase
+}
+
+func New(opts ...Option) *Sessions {
+	config := defaultConfig()
+	for _, apply := range opts {
+		apply(config)
+	}
+
+	sess := sessions.New(sessions.Config{
+		Cookie:                      config.Cookie,
+		CookieSecureTLS:             config.CookieSecureTLS,
+		AllowReclaim:                config.AllowReclaim,
+		Encoding:                    config.Encoding,
+		Expires:                     config.Expires,
+		SessionIDGenerator:          config.SessionIDGenerator,
+		DisableSubdomainPersistence: config.DisableSubdomainPersistence,
 	})
-	sess.UseDatabase(db)
+
+	var sealer *Sealer
+	if len(config.EncryptionKeys) > 0 {
+		var err error
+		if sealer, err = NewSealer(config.EncryptionKeys...); err != nil {
+			panic(err)
+		}
+	}
+	var cookieStore *cookieDatabase
+	if config.CookieStore {
+		if sealer == nil {
+			panic("EncryptionKeys 必须设置")
+		}
+		cookieStore = newCookieDatabase()
+		sess.UseDatabase(cookieStore)
+	} else if config.Database != nil {
+		if sealer != nil {
+			sess.UseDatabase(NewSealedDatabase(config.Database, sealer))
+		} else {
+			sess.UseDatabase(config.Database)
+		}
+	}
+
+	var cookieOptions []context.CookieOption
+	if config.SameSite != http.SameSiteDefaultMode {
+		cookieOptions = append(cookieOptions, context.CookieSameSite(config.SameSite))
+	}
+	if config.Domain != "" {
+		cookieOptions = append(cookieOptions, cookieDomain(config.Domain))
+	}
+	if !config.HttpOnly {
+		cookieOptions = append(cookieOptions, context.CookieHTTPOnly(false))
+	}
+
+	return &Sessions{
+		Sessions:      sess,
+		Config:        config,
+		CookieOptions: cookieOptions,
+		sealer:        sealer,
+		cookieStore:   cookieStore,
+	}
+}
+
+func cookieDomain(domain string) context.CookieOption {
+	return func(_ *context.Context, c *http.Cookie, op uint8) {
+		if op == context.OpCookieSet || op == context.OpCookieDel {
+			c.Domain = domain
+		}
+	}
+}
+
+func (s *Sessions) cookieOptions(cookieOptions []context.CookieOption) []context.CookieOption {
+	if len(cookieOptions) == 0 {
+		return s.CookieOptions
+	}
+	options := make([]context.CookieOption, 0, len(s.CookieOptions)+len(cookieOptions))
+	return append(append(options, s.CookieOptions...), cookieOptions...)
+}
+
+func (s *Sessions) Start(ctx iris.Context, cookieOptions ...context.CookieOption) *sessions.Session {
+	sess := s.Sessions.Start(ctx, s.cookieOptions(cookieOptions)...)
+	if s.cookieStore != nil {
+		s.loadCookie(ctx, sess)
+	}
 	return sess
 }
+
+func (s *Sessions) Handler(cookieOptions ...context.CookieOption) iris.Handler {
+	return func(ctx iris.Context) {
+		if s.cookieStore != nil {
+			//the sealed cookie is written after the chain, the response has to be buffered until then
+			ctx.Record()
+			defer s.endCookies(ctx)
+		}
+		sess := s.Start(ctx, cookieOptions...)
+		if s.expired(sess) {
+			s.DestroyByID(sess.ID())
+			s.removeRequestCookie(ctx)
+			sess = s.Start(ctx, cookieOptions...)
+		}
+		s.touch(sess)
+		ctx.Values().Set(sessionContextKey, sess)
+		ctx.Values().Set(managerContextKey, s)
+		ctx.Next()
+		if s.cookieStore != nil {
+			s.saveCookie(ctx)
+		}
+	}
+}
+
+func (s *Sessions) ShiftExpiration(ctx iris.Context, cookieOptions ...context.CookieOption) error {
+	return s.Sessions.ShiftExpiration(ctx, s.cookieOptions(cookieOptions)...)
+}
+
+func (s *Sessions) UpdateExpiration(ctx iris.Context, expires time.Duration, cookieOptions ...context.CookieOption) error {
+	return s.Sessions.UpdateExpiration(ctx, expires, s.cookieOptions(cookieOptions)...)
+}
+
+// GetSessions returns the Sessions whose Handler served the current request.
+func GetSessions(ctx iris.Context) *Sessions {
+	if v := ctx.Values().Get(managerContextKey); v != nil {
+		if s, ok := v.(*Sessions); ok {
+			return s
+		}
+	}
+	return nil
+}
+
+func ParseSameSite(val string) http.SameSite {
+	switch strings.ToLower(val) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteDefaultMode
+	}
+}
+
+// NewWithConfig builds the sessions from the "session.*" keys, "session.backend" selects redis (default when a client is given), memory, boltdb or cookie.
+// "session.encryptionKeys" holds base64 AES keys, the first one encrypts. Keys which aren't set keep the defaults of New.
+func NewWithConfig(c *config.Config, client redis.UniversalClient, opts ...Option) *Sessions {
+	var options []Option
+	if c.IsSet("session.cookieName") {
+		options = append(options, WithCookie(c.GetString("session.cookieName")))
+	}
+	if c.IsSet("session.maxAge") {
+		options = append(options, WithExpires(c.GetDuration("session.maxAge")))
+	}
+	if c.IsSet("session.sameSite") {
+		options = append(options, WithSameSite(ParseSameSite(c.GetString("session.sameSite"))))
+	}
+	if c.IsSet("session.domain") {
+		options = append(options, WithDomain(c.GetString("session.domain")))
+	}
+	if c.IsSet("session.idleTimeout") {
+		options = append(options, WithIdleTimeout(c.GetDuration("session.idleTimeout")))
+	}
+	if c.IsSet("session.absoluteTimeout") {
+		options = append(options, WithAbsoluteTimeout(c.GetDuration("session.absoluteTimeout")))
+	}
+	if c.IsSet("session.cookieSecureTLS") {
+		options = append(options, WithCookieSecureTLS(c.GetBool("session.cookieSecureTLS")))
+	}
+	if c.IsSet("session.allowReclaim") {
+		options = append(options, WithAllowReclaim(c.GetBool("session.allowReclaim")))
+	}
+	if c.IsSet("session.disableSubdomainPersistence") {
+		options = append(options, WithDisableSubdomainPersistence(c.GetBool("session.disableSubdomainPersistence")))
+	}
+	if c.IsSet("session.httpOnly") {
+		options = append(options, WithHttpOnly(c.GetBool("session.httpOnly")))
+	}
+	if values := c.GetStringSlice("session.encryptionKeys"); len(values) > 0 {
+		keys, err := ParseKeys(values...)
+		if err != nil {
+			panic(err)
+		}
+		options = append(options, WithEncryptionKeys(keys...))
+	}
+	if c.GetString("session.backend") == BackendCookie {
+		options = append(options, WithCookieStore(true))
+	} else if db := NewDatabaseWithConfig(c, client); db != nil {
+		options = append(options, WithDatabase(db))
+	}
+	return New(append(options, opts...)...)
+}
+
+// NewSessionsWithConfig builds the sessions of NewWithConfig, register them with their Handler so that the cookie options,
+// the cookie backend, the timeouts and ctx.RotateSession apply.
+// "session.maxAge" defaults to 2h, the sessions built before NewWithConfig never expired when it wasn't set.
+func NewSessionsWithConfig(c *config.Config, client *redis.Client) *Sessions {
+	//a nil *redis.Client must not become a non nil client
+	var universal redis.UniversalClient
+	if client != nil {
+		universal = client
+	}
+	return NewWithConfig(c, universal)
+}
@@ -0,0 +1,124 @@
+package session
+
+import (
+	"encoding/base64"
+	"github.com/go-tron/config"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/response"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/kataras/iris/v12/sessions"
+	"github.com/spf13/viper"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func init() {
+	baseContext.New("test", nil, baseContext.WithResponse(response.New()))
+}
+
+func newConfig(values map[string]interface{}) *config.Config {
+	v := viper.New()
+	for key, value := range values {
+		v.Set(key, value)
+	}
+	return &config.Config{Viper: v}
+}
+
+// newApp serves /set?name=, /get and /rotate, the handler runs after the given middlewares.
+func newApp(s *Sessions, middlewares ...iris.Handler) *iris.Application {
+	app := iris.New()
+	app.Use(s.Handler())
+	app.Use(middlewares...)
+	app.Get("/set", baseContext.Handler(func(ctx *baseContext.Context) {
+		sessions.Get(ctx.Context).Set("name", ctx.URLParam("name"))
+		ctx.WriteString(sessions.Get(ctx.Context).ID())
+	}))
+	app.Get("/get", baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.WriteString(sessions.Get(ctx.Context).GetString("name"))
+	}))
+	app.Get("/id", baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.WriteString(sessions.Get(ctx.Context).ID())
+	}))
+	app.Get("/rotate", baseContext.Handler(func(ctx *baseContext.Context) {
+		if err := ctx.RotateSession("secret"); err != nil {
+			ctx.Error(err)
+			return
+		}
+		ctx.WriteString(sessions.Get(ctx.Context).ID())
+	}))
+	return app
+}
+
+func newExpect(t *testing.T, app *iris.Application) *httptest.Expect {
+	return httptest.New(t, app, httptest.URL("http://example.com"))
+}
+
+func TestMemory(t *testing.T) {
+	s := New(WithCookie("sid"), WithSameSite(http.SameSiteStrictMode), WithDomain("example.com"))
+	e := newExpect(t, newApp(s))
+
+	resp := e.GET("/set").WithQuery("name", "a").Expect().Status(iris.StatusOK)
+	cookie := resp.Cookie("sid")
+	cookie.Domain().IsEqual("example.com")
+	if c := resp.Raw().Cookies()[0]; c.SameSite != http.SameSiteStrictMode || !c.HttpOnly {
+		t.Fatalf("cookie %+v", c)
+	}
+	e.GET("/get").Expect().Body().IsEqual("a")
+}
+
+func TestNewWithConfigDefaults(t *testing.T) {
+	s := NewWithConfig(newConfig(map[string]interface{}{"session.backend": BackendMemory}), nil)
+	if s.Cookie != sessions.DefaultCookieName || s.Expires != 2*time.Hour || s.SameSite != 0 ||
+		!s.HttpOnly || !s.CookieSecureTLS || s.Database != nil {
+		t.Fatalf("config %+v", s.Config)
+	}
+
+	s = NewWithConfig(newConfig(map[string]interface{}{
+		"session.backend":     BackendMemory,
+		"session.cookieName":  "sid",
+		"session.maxAge":      "30m",
+		"session.sameSite":    "lax",
+		"session.idleTimeout": "5m",
+		"session.httpOnly":    false,
+	}), nil)
+	if s.Cookie != "sid" || s.Expires != 30*time.Minute || s.SameSite != http.SameSiteLaxMode ||
+		s.IdleTimeout != 5*time.Minute || s.HttpOnly {
+		t.Fatalf("config %+v", s.Config)
+	}
+}
+
+func TestNewSessionsWithConfig(t *testing.T) {
+	s := NewSessionsWithConfig(newConfig(map[string]interface{}{
+		"session.backend":        BackendCookie,
+		"session.encryptionKeys": []string{base64.StdEncoding.EncodeToString(testKey)},
+		"session.sameSite":       "strict",
+	}), nil)
+	if s.cookieStore == nil || s.Expires != 2*time.Hour || len(s.CookieOptions) == 0 {
+		t.Fatalf("sessions %+v", s.Config)
+	}
+}
+
+func TestCookieOptionsNotShared(t *testing.T) {
+	s := New(WithSameSite(http.SameSiteLaxMode), WithDomain("example.com"))
+	s.CookieOptions = append(make([]context.CookieOption, 0, 8), s.CookieOptions...)
+	n := len(s.CookieOptions)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if options := s.cookieOptions([]context.CookieOption{context.CookieHTTPOnly(false)}); len(options) != n+1 {
+				t.Errorf("options %d", len(options))
+			}
+		}()
+	}
+	wg.Wait()
+	if len(s.CookieOptions) != n {
+		t.Fatalf("options %d", len(s.CookieOptions))
+	}
+}
@@ -159,6 +159,11 @@ func (s *Signature) CheckPath(currPath string) Level {
 	return defaultLevel
 }
 
+func (s *Signature) reject(ctx *baseContext.Context, err error) {
+	ctx.RecordEvent(baseContext.EventAuthFailure, "signature")
+	ctx.Error(err)
+}
+
 func (s *Signature) Context(ctx *baseContext.Context) {
 	level := s.CheckPath(ctx.Request().URL.Path)
 	if level == LevelIgnore {
@@ -190,23 +195,23 @@ func (s *Signature) Context(ctx *baseContext.Context) {
 		case float64:
 			t = int64(v)
 		default:
-			ctx.Error(ErrorNoTimestamp(s.Config.Timestamp.Property))
+			s.reject(ctx, ErrorNoTimestamp(s.Config.Timestamp.Property))
 			return
 		}
 
 		tm := time.Unix(t/int64(time.Second/s.Config.Timestamp.Unit), 0)
 		if time.Until(tm) > 10*time.Second {
-			ctx.Error(ErrorTimestampAfterNow(s.Config.Timestamp.Property))
+			s.reject(ctx, ErrorTimestampAfterNow(s.Config.Timestamp.Property))
 			return
 		}
 		if time.Since(tm) > s.Config.Timestamp.Duration {
-			ctx.Error(ErrorTimestampExpired(s.Config.Timestamp.Property, s.Config.Timestamp.Duration))
+			s.reject(ctx, ErrorTimestampExpired(s.Config.Timestamp.Property, s.Config.Timestamp.Duration))
 			return
 		}
 	}
 
 	if err := s.Signer.Verify(params); err != nil {
-		ctx.Error(err)
+		s.reject(ctx, err)
 		return
 	}
 	ctx.Next()
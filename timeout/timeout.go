@@ -0,0 +1,206 @@
+package timeout
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-tron/base-error"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/pathRule"
+	"github.com/kataras/iris/v12"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrorTimeout = baseError.Factory("4360", "request timeout after {}")
+)
+
+type Option func(*Config)
+
+func defaultConfig() *Config {
+	return &Config{
+		Duration:       30 * time.Second,
+		DeadlineHeader: "X-Request-Deadline",
+	}
+}
+
+func WithDuration(val time.Duration) Option {
+	return func(opts *Config) {
+		opts.Duration = val
+	}
+}
+
+// WithDeadlineHeader sets the header carrying the upstream deadline in unix milliseconds, empty disables it.
+func WithDeadlineHeader(val string) Option {
+	return func(opts *Config) {
+		opts.DeadlineHeader = val
+	}
+}
+func WithPath(path interface{}, duration time.Duration) Option {
+	return func(opts *Config) {
+		opts.Paths = append(opts.Paths, PathConfig{path, duration})
+	}
+}
+func WithPaths(paths ...PathConfig) Option {
+	return func(opts *Config) {
+		opts.Paths = append(opts.Paths, paths...)
+	}
+}
+func WithIgnorePaths(paths ...interface{}) Option {
+	return func(opts *Config) {
+		for _, path := range paths {
+			opts.Paths = append(opts.Paths, PathConfig{path, 0})
+		}
+	}
+}
+
+// PathConfig Duration 0 means no timeout.
+type PathConfig struct {
+	Name     interface{}
+	Duration time.Duration
+}
+
+type Config struct {
+	Duration       time.Duration
+	DeadlineHeader string
+	Paths          []PathConfig
+}
+
+type Timeout struct {
+	*Config
+}
+
+func New(opts ...Option) *Timeout {
+	config := defaultConfig()
+	for _, apply := range opts {
+		apply(config)
+	}
+	return &Timeout{
+		Config: config,
+	}
+}
+
+func (t *Timeout) CheckPath(currPath string) time.Duration {
+	var duration = t.Duration
+	for _, path := range t.Paths {
+		if pathRule.Match(path.Name, currPath) {
+			duration = path.Duration
+			break
+		}
+	}
+	return duration
+}
+
+func (t *Timeout) upstreamDeadline(ctx *baseContext.Context) (time.Time, bool) {
+	if t.DeadlineHeader == "" {
+		return time.Time{}, false
+	}
+	v := ctx.GetHeader(t.DeadlineHeader)
+	if v == "" {
+		return time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || ms <= 0 {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms), true
+}
+
+func (t *Timeout) response(ctx *baseContext.Context, err *baseError.Error, requestId string) (string, []byte) {
+	resp := ctx.Response.Error(err.Code, err.Msg)
+	if requestId != "" {
+		resp.WithRid(requestId)
+	}
+	switch resp.ContentType() {
+	case "text":
+		return "text/plain; charset=utf-8", []byte(resp.Content().(string))
+	case "binary":
+		return "application/octet-stream", resp.Content().([]byte)
+	}
+	body, _ := json.Marshal(resp.Content())
+	return "application/json; charset=utf-8", body
+}
+
+func (t *Timeout) Context(ctx *baseContext.Context) {
+	duration := t.CheckPath(ctx.Request().URL.Path)
+	start := time.Now()
+	var deadline time.Time
+	if duration > 0 {
+		deadline = start.Add(duration)
+	}
+	if upstream, ok := t.upstreamDeadline(ctx); ok && (deadline.IsZero() || upstream.Before(deadline)) {
+		deadline = upstream
+	}
+	if deadline.IsZero() {
+		ctx.Next()
+		return
+	}
+
+	requestCtx, cancel := context.WithDeadline(ctx.Request().Context(), deadline)
+	defer cancel()
+	//reset first, without a trace ctx the context itself is the parent and its timer reads the request
+	ctx.ResetRequest(ctx.Request().WithContext(requestCtx))
+	traceCtx, cancelTrace := context.WithDeadline(ctx.GetTraceCtx(), deadline)
+	defer cancelTrace()
+	ctx.SetTraceCtx(traceCtx)
+
+	var writer *timeoutWriter
+	if rec, ok := ctx.IsRecording(); ok {
+		writer = newTimeoutWriter(rec.ResponseWriter)
+		rec.ResponseWriter = writer
+	} else {
+		writer = newTimeoutWriter(ctx.ResponseWriter())
+		ctx.ResetResponseWriter(writer)
+	}
+	requestId := ctx.Values().GetString("requestId")
+
+	done := make(chan interface{}, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				//the stack is lost once the value is re-panicked on the request goroutine
+				done <- baseContext.NewRecoveredPanic(p)
+				return
+			}
+			done <- nil
+		}()
+		ctx.Next()
+	}()
+
+	select {
+	case p := <-done:
+		if p != nil {
+			panic(p)
+		}
+		return
+	case <-requestCtx.Done():
+	}
+
+	var (
+		err  *baseError.Error
+		body []byte
+		sent bool
+	)
+	if requestCtx.Err() == context.DeadlineExceeded {
+		var contentType string
+		err = ErrorTimeout(deadline.Sub(start).Round(time.Millisecond))
+		contentType, body = t.response(ctx, err, requestId)
+		sent = writer.timeout(contentType, body)
+	}
+
+	//the 504 is already flushed, the chain still owns the pooled context so it is only released once the chain returns
+	if p := <-done; p != nil {
+		panic(p)
+	}
+	if err != nil {
+		ctx.Values().Set("error", err)
+	}
+	if rec, ok := ctx.IsRecording(); ok && sent {
+		//the recorder holds the late response of the chain, it is dropped on flush but would be logged
+		rec.SetBody(body)
+	}
+}
+
+func (t *Timeout) Handler() iris.Handler {
+	return baseContext.Handler(t.Context)
+}
@@ -0,0 +1,171 @@
+package timeout
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-tron/iris/baseContext"
+	irisRecover "github.com/go-tron/iris/recover"
+	"github.com/go-tron/iris/response"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"io"
+	"net/http"
+	nethttptest "net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func init() {
+	baseContext.New("test", nil, baseContext.WithResponse(response.New()))
+}
+
+type recorded struct {
+	status int
+	body   string
+}
+
+func newApp(t *Timeout, recordings chan recorded) *iris.Application {
+	app := iris.New()
+	if recordings != nil {
+		app.Use(func(ctx iris.Context) {
+			ctx.Record()
+			ctx.Next()
+			recordings <- recorded{ctx.GetStatusCode(), string(ctx.Recorder().Body())}
+		})
+	}
+	app.Use(t.Handler())
+	app.Get("/fast", baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.WriteString("fast")
+	}))
+	app.Get("/slow", baseContext.Handler(func(ctx *baseContext.Context) {
+		//ignores the deadline on purpose
+		time.Sleep(300 * time.Millisecond)
+		ctx.StatusCode(iris.StatusCreated)
+		ctx.WriteString("late")
+	}))
+	app.Get("/aware", baseContext.Handler(func(ctx *baseContext.Context) {
+		select {
+		case <-ctx.Request().Context().Done():
+		case <-time.After(time.Second):
+			ctx.WriteString("late")
+		}
+	}))
+	app.Get("/panic", baseContext.Handler(func(ctx *baseContext.Context) {
+		panic("boom")
+	}))
+	return app
+}
+
+func TestFast(t *testing.T) {
+	e := httptest.New(t, newApp(New(WithDuration(time.Second)), nil))
+	e.GET("/fast").Expect().Status(iris.StatusOK).Body().IsEqual("fast")
+}
+
+func TestTimeout(t *testing.T) {
+	e := httptest.New(t, newApp(New(WithDuration(50*time.Millisecond)), nil))
+	e.GET("/aware").Expect().Status(iris.StatusGatewayTimeout).
+		JSON().Object().Value("code").IsEqual("4360")
+}
+
+func TestTimeoutFlushedBeforeHandlerReturns(t *testing.T) {
+	recordings := make(chan recorded, 1)
+	app := newApp(New(WithDuration(50*time.Millisecond)), recordings)
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+	srv := nethttptest.NewServer(app)
+	defer srv.Close()
+
+	start := time.Now()
+	resp, err := http.Get(srv.URL + "/slow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= 250*time.Millisecond {
+		t.Fatalf("the 504 waited for the handler, %s", elapsed)
+	}
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	var envelope map[string]interface{}
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope["code"] != "4360" {
+		t.Fatalf("body %s", body)
+	}
+
+	select {
+	case r := <-recordings:
+		if r.status != http.StatusGatewayTimeout || r.body != string(body) {
+			t.Fatalf("recorded %d %s", r.status, r.body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the chain never returned")
+	}
+}
+
+func TestDeadlineHeader(t *testing.T) {
+	e := httptest.New(t, newApp(New(WithDuration(time.Minute)), nil))
+	deadline := time.Now().Add(50 * time.Millisecond).UnixMilli()
+	e.GET("/aware").WithHeader("X-Request-Deadline", strconv.FormatInt(deadline, 10)).Expect().
+		Status(iris.StatusGatewayTimeout)
+}
+
+func TestIgnorePaths(t *testing.T) {
+	to := New(WithDuration(time.Millisecond), WithIgnorePaths("/fast"))
+	if d := to.CheckPath("/fast"); d != 0 {
+		t.Fatalf("duration %s", d)
+	}
+	e := httptest.New(t, newApp(to, nil))
+	e.GET("/fast").Expect().Status(iris.StatusOK)
+}
+
+func TestPanic(t *testing.T) {
+	app := newApp(New(WithDuration(time.Second)), nil)
+	defer func() {
+		p, ok := recover().(*baseContext.RecoveredPanic)
+		if !ok || p.Value != "boom" || !strings.Contains(string(p.Stack), "timeout_test.go") {
+			t.Fatalf("recovered %v", p)
+		}
+	}()
+	httptest.New(t, app).GET("/panic").Expect()
+}
+
+func TestPanicKeepsStack(t *testing.T) {
+	infos := make(chan *irisRecover.PanicInfo, 2)
+	app := iris.New()
+	app.Use(irisRecover.New(irisRecover.WithReporters(irisRecover.PanicReporterFunc(func(info *irisRecover.PanicInfo) {
+		infos <- info
+	}))))
+	app.Use(New(WithDuration(time.Second)).Handler())
+	app.Get("/a", func(ctx iris.Context) {
+		panic("a")
+	})
+	app.Get("/b", func(ctx iris.Context) {
+		panic("b")
+	})
+	e := httptest.New(t, app)
+	e.GET("/a").Expect().Status(iris.StatusInternalServerError)
+	e.GET("/b").Expect().Status(iris.StatusInternalServerError)
+
+	var fingerprints []string
+	for i := 0; i < 2; i++ {
+		select {
+		case info := <-infos:
+			if !strings.Contains(string(info.Stack), "timeout_test.go") || !strings.Contains(fmt.Sprintf("%+v", info.Error), "timeout_test.go") {
+				t.Fatalf("stack %s", info.Stack)
+			}
+			fingerprints = append(fingerprints, info.Fingerprint)
+		case <-time.After(time.Second):
+			t.Fatal("no report")
+		}
+	}
+	if fingerprints[0] == fingerprints[1] {
+		t.Fatalf("fingerprints %v", fingerprints)
+	}
+}
@@ -0,0 +1,117 @@
+package timeout
+
+import (
+	"github.com/kataras/iris/v12/context"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// timeoutWriter sits under the handler chain, once the deadline has passed every late write of the handler is dropped.
+type timeoutWriter struct {
+	context.ResponseWriter
+	mu       sync.Mutex
+	header   http.Header
+	timedOut bool
+}
+
+func newTimeoutWriter(w context.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{
+		ResponseWriter: w,
+		header:         w.Header().Clone(),
+	}
+}
+
+func (w *timeoutWriter) syncHeader() {
+	h := w.ResponseWriter.Header()
+	for k := range h {
+		if _, ok := w.header[k]; !ok {
+			delete(h, k)
+		}
+	}
+	for k, v := range w.header {
+		h[k] = v
+	}
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) Write(contents []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	w.syncHeader()
+	return w.ResponseWriter.Write(contents)
+}
+
+func (w *timeoutWriter) WriteHeader(statusCode int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *timeoutWriter) StatusCode() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.ResponseWriter.StatusCode()
+}
+
+func (w *timeoutWriter) Written() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.ResponseWriter.Written()
+}
+
+func (w *timeoutWriter) SetWritten(n int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return
+	}
+	w.ResponseWriter.SetWritten(n)
+}
+
+func (w *timeoutWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return
+	}
+	w.syncHeader()
+	w.ResponseWriter.Flush()
+}
+
+func (w *timeoutWriter) FlushResponse() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.timedOut {
+		w.syncHeader()
+	}
+	w.ResponseWriter.FlushResponse()
+}
+
+// timeout marks the writer as timed out and sends the given response if nothing has been sent yet,
+// it reports whether it did.
+func (w *timeoutWriter) timeout(contentType string, body []byte) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = true
+	if w.ResponseWriter.Written() != context.NoWritten {
+		return false
+	}
+	h := w.ResponseWriter.Header()
+	h.Set("Content-Type", contentType)
+	//with the length known the client has the whole response on flush, it doesn't wait for the late handler
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeader(http.StatusGatewayTimeout)
+	w.ResponseWriter.Write(body)
+	w.ResponseWriter.Flush()
+	return true
+}
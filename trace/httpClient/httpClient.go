@@ -0,0 +1,176 @@
+package httpClient
+
+import (
+	"bytes"
+	baseError "github.com/go-tron/base-error"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/response"
+	"github.com/go-tron/iris/trace"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+)
+
+const (
+	instrumentationName = "github.com/go-tron/iris/trace/httpClient"
+	maxEnvelopeSize     = 1 << 20
+)
+
+type Option func(*Config)
+
+func defaultConfig() *Config {
+	return &Config{
+		RequestIdHeader: trace.DefaultRequestIdHeader,
+	}
+}
+
+func WithTracerProvider(val oteltrace.TracerProvider) Option {
+	return func(opts *Config) {
+		opts.TracerProvider = val
+	}
+}
+func WithPropagator(val propagation.TextMapPropagator) Option {
+	return func(opts *Config) {
+		opts.Propagator = val
+	}
+}
+func WithRequestIdHeader(val string) Option {
+	return func(opts *Config) {
+		opts.RequestIdHeader = val
+	}
+}
+
+type Config struct {
+	TracerProvider  oteltrace.TracerProvider
+	Propagator      propagation.TextMapPropagator
+	RequestIdHeader string
+}
+
+// Transport creates a client span per call and injects the trace headers and the request id taken from the request context,
+// build the request with ctx.GetTraceCtx() for it to work.
+type Transport struct {
+	*Config
+	Base   http.RoundTripper
+	tracer oteltrace.Tracer
+}
+
+func New(base http.RoundTripper, opts ...Option) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	config := defaultConfig()
+	for _, apply := range opts {
+		apply(config)
+	}
+	if config.TracerProvider == nil {
+		config.TracerProvider = otel.GetTracerProvider()
+	}
+	if config.Propagator == nil {
+		config.Propagator = otel.GetTextMapPropagator()
+		if len(config.Propagator.Fields()) == 0 {
+			config.Propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+		}
+	}
+	return &Transport{
+		Config: config,
+		Base:   base,
+		tracer: config.TracerProvider.Tracer(instrumentationName),
+	}
+}
+
+// NewClient returns a copy of client using the tracing transport.
+func NewClient(client *http.Client, opts ...Option) *http.Client {
+	if client == nil {
+		client = &http.Client{}
+	}
+	c := *client
+	c.Transport = New(client.Transport, opts...)
+	return &c
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	ctx, span := t.tracer.Start(req.Context(), "HTTP "+req.Method,
+		oteltrace.WithSpanKind(oteltrace.SpanKindClient),
+		oteltrace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.URLFull(req.URL.Redacted()),
+			semconv.ServerAddress(req.URL.Hostname()),
+		),
+	)
+	defer span.End()
+
+	//a RoundTripper must not modify the caller's request
+	req = req.Clone(ctx)
+	t.Propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	trace.InjectRequestId(ctx, req.Header, t.RequestIdHeader)
+
+	if parent := opentracing.SpanFromContext(ctx); parent != nil {
+		otSpan := opentracing.StartSpan(req.URL.Path+":C:", opentracing.ChildOf(parent.Context()))
+		ext.SpanKindRPCClient.Set(otSpan)
+		ext.HTTPMethod.Set(otSpan, req.Method)
+		ext.HTTPUrl.Set(otSpan, req.URL.Redacted())
+		defer otSpan.Finish()
+		otSpan.Tracer().Inject(otSpan.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
+	}
+
+	resp, err := t.Base.RoundTrip(req)
+	span.SetAttributes(attribute.Int64("http.client.duration_ms", time.Since(start).Milliseconds()))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// ParseError reads the response.Response envelope of a downstream and returns it as a *baseError.Error when its code isn't a success,
+// the body is restored so the response can still be read. The transport leaves responses untouched, call it on the response it returned.
+func ParseError(resp *http.Response) *baseError.Error {
+	if resp == nil || resp.Body == nil {
+		return nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "application/json" {
+		return nil
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxEnvelopeSize+1))
+	rest := resp.Body
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), rest), rest}
+	if err != nil || len(data) > maxEnvelopeSize {
+		return nil
+	}
+
+	r, err := response.Decode(data)
+	if err != nil || r.Code == "" || r.IsSuccess() {
+		return nil
+	}
+	e := r.ToError()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		e.WithSystem()
+	}
+	return e
+}
+
+// ChainError returns the error of a downstream response chained like ctx.ChainError, nil when the downstream succeeded.
+func ChainError(ctx *baseContext.Context, service string, resp *http.Response) error {
+	if e := ParseError(resp); e != nil {
+		return ctx.ChainError(e, service)
+	}
+	return nil
+}
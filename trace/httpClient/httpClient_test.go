@@ -0,0 +1,179 @@
+package httpClient
+
+import (
+	"context"
+	"errors"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/response"
+	"github.com/go-tron/iris/trace"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"io"
+	"net/http"
+	nethttptest "net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func init() {
+	baseContext.New("test", nil, baseContext.WithResponse(response.New()), baseContext.WithApplicationName("gateway"))
+}
+
+func newDownstream(t *testing.T) *nethttptest.Server {
+	server := nethttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/headers":
+			w.Write([]byte(r.Header.Get("traceparent") + "," + r.Header.Get(trace.DefaultRequestIdHeader)))
+		case "/ok":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"code":"00","message":"","data":1}`))
+		case "/business":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"1001","message":"bad","chain":"user"}`))
+		case "/system":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"code":"100","message":"down"}`))
+		case "/text":
+			w.Write([]byte(`{"code":"1001"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newProvider(t *testing.T) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return provider, exporter
+}
+
+func TestPropagation(t *testing.T) {
+	downstream := newDownstream(t)
+	provider, exporter := newProvider(t)
+	client := NewClient(nil, WithTracerProvider(provider))
+
+	app := iris.New()
+	app.Use(trace.New())
+	app.Use(trace.NewOtel(trace.WithTracerProvider(provider)).Handler())
+	app.Get("/", baseContext.Handler(func(ctx *baseContext.Context) {
+		req, _ := http.NewRequestWithContext(ctx.GetTraceCtx(), http.MethodGet, downstream.URL+"/headers", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		if req.Header.Get("traceparent") != "" {
+			ctx.Error(errors.New("the caller's request was modified"))
+			return
+		}
+		body, _ := io.ReadAll(resp.Body)
+		ctx.Write(body)
+	}))
+	body := httptest.New(t, app).GET("/").WithHeader(trace.DefaultRequestIdHeader, "rid-1").
+		Expect().Status(iris.StatusOK).Body().Raw()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 || spans[0].SpanKind.String() != "client" || spans[0].Parent.SpanID() != spans[1].SpanContext.SpanID() {
+		t.Fatalf("spans %+v", spans)
+	}
+	sc := spans[0].SpanContext
+	if body != "00-"+sc.TraceID().String()+"-"+sc.SpanID().String()+"-01,rid-1" {
+		t.Fatalf("body %s", body)
+	}
+}
+
+func TestTransportIsPure(t *testing.T) {
+	downstream := newDownstream(t)
+	provider, exporter := newProvider(t)
+	client := NewClient(nil, WithTracerProvider(provider))
+
+	//error envelopes are returned as responses
+	resp, err := client.Get(downstream.URL + "/business")
+	if err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("resp %v err %v", resp, err)
+	}
+	resp.Body.Close()
+
+	resp, err = client.Get(downstream.URL + "/system")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if status := exporter.GetSpans()[1].Status; status.Code != codes.Error {
+		t.Fatalf("status %+v", status)
+	}
+
+	//transport errors keep the RoundTripper contract
+	if _, err := client.Get("http://127.0.0.1:0/"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if status := exporter.GetSpans()[2].Status; status.Code != codes.Error {
+		t.Fatalf("status %+v", status)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	downstream := newDownstream(t)
+	get := func(path string) *http.Response {
+		resp, err := http.Get(downstream.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	if e := ParseError(get("/ok")); e != nil {
+		t.Fatalf("error %v", e)
+	}
+	if e := ParseError(get("/text")); e != nil {
+		t.Fatalf("error %v", e)
+	}
+
+	resp := get("/business")
+	e := ParseError(resp)
+	if e == nil || e.Code != "1001" || e.Msg != "bad" || e.System || e.Chain != "user" {
+		t.Fatalf("error %+v", e)
+	}
+	//the body can still be read
+	if body, _ := io.ReadAll(resp.Body); !strings.Contains(string(body), `"code":"1001"`) {
+		t.Fatalf("body %s", body)
+	}
+
+	if e := ParseError(get("/system")); e == nil || !e.System {
+		t.Fatalf("error %+v", e)
+	}
+}
+
+func TestChainError(t *testing.T) {
+	downstream := newDownstream(t)
+	app := iris.New()
+	app.Get("/{path}", baseContext.Handler(func(ctx *baseContext.Context) {
+		resp, err := http.Get(downstream.URL + "/" + ctx.Params().Get("path"))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		if err := ChainError(ctx, "order", resp); err != nil {
+			ctx.Error(err)
+			return
+		}
+		ctx.Success()
+	}))
+	e := httptest.New(t, app)
+	e.GET("/ok").Expect().JSON().Object().Value("code").IsEqual("00")
+	body := e.GET("/business").Expect().JSON().Object()
+	body.Value("code").IsEqual("1001")
+	body.Value("chain").IsEqual("user<-gateway")
+	body = e.GET("/system").Expect().JSON().Object()
+	body.Value("system").IsEqual(true)
+	body.Value("chain").IsEqual("order<-gateway")
+}
@@ -0,0 +1,152 @@
+package trace
+
+import (
+	"github.com/go-tron/iris/baseContext"
+	"github.com/kataras/iris/v12"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+const instrumentationName = "github.com/go-tron/iris/trace"
+
+type OtelOption func(*OtelConfig)
+
+func defaultOtelConfig() *OtelConfig {
+	return &OtelConfig{}
+}
+
+// WithTracerProvider defaults to the global provider, pass one with an in-memory exporter in tests.
+func WithTracerProvider(val oteltrace.TracerProvider) OtelOption {
+	return func(opts *OtelConfig) {
+		opts.TracerProvider = val
+	}
+}
+
+// WithPropagator defaults to the global propagator, or W3C traceparent/tracestate and baggage when none was set.
+func WithPropagator(val propagation.TextMapPropagator) OtelOption {
+	return func(opts *OtelConfig) {
+		opts.Propagator = val
+	}
+}
+func WithSpanNameFormatter(val func(ctx *baseContext.Context) string) OtelOption {
+	return func(opts *OtelConfig) {
+		opts.SpanNameFormatter = val
+	}
+}
+
+type OtelConfig struct {
+	TracerProvider    oteltrace.TracerProvider
+	Propagator        propagation.TextMapPropagator
+	SpanNameFormatter func(ctx *baseContext.Context) string
+}
+
+type Otel struct {
+	*OtelConfig
+	tracer oteltrace.Tracer
+}
+
+func NewOtel(opts ...OtelOption) *Otel {
+	config := defaultOtelConfig()
+	for _, apply := range opts {
+		apply(config)
+	}
+	if config.TracerProvider == nil {
+		config.TracerProvider = otel.GetTracerProvider()
+	}
+	if config.Propagator == nil {
+		config.Propagator = propagator()
+	}
+	return &Otel{
+		OtelConfig: config,
+		tracer:     config.TracerProvider.Tracer(instrumentationName),
+	}
+}
+
+// propagator returns the global one unless it is still the default no-op.
+func propagator() propagation.TextMapPropagator {
+	p := otel.GetTextMapPropagator()
+	if len(p.Fields()) == 0 {
+		return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	}
+	return p
+}
+
+func routeTemplate(ctx *baseContext.Context) string {
+	if route := ctx.GetCurrentRoute(); route != nil {
+		return route.Path()
+	}
+	return ""
+}
+
+func (t *Otel) spanName(ctx *baseContext.Context) string {
+	if t.SpanNameFormatter != nil {
+		return t.SpanNameFormatter(ctx)
+	}
+	if route := routeTemplate(ctx); route != "" {
+		return ctx.Method() + " " + route
+	}
+	return ctx.Method()
+}
+
+func (t *Otel) Context(ctx *baseContext.Context) {
+	r := ctx.Request()
+	parent := t.Propagator.Extract(ctx.GetTraceCtx(), propagation.HeaderCarrier(r.Header))
+
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(r.Method),
+		semconv.URLPath(r.URL.Path),
+		semconv.ServerAddress(r.Host),
+		semconv.ClientAddress(ctx.GetIP()),
+	}
+	if r.TLS != nil {
+		attrs = append(attrs, semconv.URLScheme("https"))
+	} else {
+		attrs = append(attrs, semconv.URLScheme("http"))
+	}
+	if route := routeTemplate(ctx); route != "" {
+		attrs = append(attrs, semconv.HTTPRoute(route))
+	}
+	if ua := r.UserAgent(); ua != "" {
+		attrs = append(attrs, semconv.UserAgentOriginal(ua))
+	}
+	if requestId := ctx.Values().GetString("requestId"); requestId != "" {
+		attrs = append(attrs, attribute.String("request.id", requestId))
+	}
+
+	traceCtx, span := t.tracer.Start(parent, t.spanName(ctx),
+		oteltrace.WithSpanKind(oteltrace.SpanKindServer),
+		oteltrace.WithAttributes(attrs...),
+	)
+	defer func() {
+		if p := recover(); p != nil {
+			span.SetStatus(codes.Error, "panic")
+			span.End()
+			panic(p)
+		}
+		status := ctx.GetStatusCode()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if err, ok := ctx.Values().Get("error").(error); ok {
+			baseContext.RecordSpanError(span, err)
+		} else if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		span.End()
+	}()
+
+	if sc := span.SpanContext(); sc.IsValid() {
+		ctx.Values().Set("traceId", sc.TraceID().String())
+		ctx.Values().Set("spanId", sc.SpanID().String())
+	}
+	ctx.SetTraceCtx(traceCtx)
+	ctx.ResetRequest(r.WithContext(oteltrace.ContextWithSpan(r.Context(), span)))
+	ctx.Next()
+}
+
+func (t *Otel) Handler() iris.Handler {
+	return baseContext.Handler(t.Context)
+}
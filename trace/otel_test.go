@@ -0,0 +1,111 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func newOtelApp(t *testing.T) (*httptest.Expect, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	app := iris.New()
+	app.Logger().SetLevel("disable")
+	app.Use(func(ctx iris.Context) {
+		defer func() {
+			if p := recover(); p != nil {
+				ctx.StopWithStatus(iris.StatusInternalServerError)
+			}
+		}()
+		ctx.Next()
+	})
+	app.Use(NewOtel(WithTracerProvider(provider)).Handler())
+	app.Get("/users/{id}", baseContext.Handler(func(ctx *baseContext.Context) {
+		span := ctx.StartSpan("load")
+		span.End()
+		ctx.WriteString(ctx.Values().GetString("traceId") + "," + ctx.Values().GetString("spanId"))
+	}))
+	app.Get("/business", baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.Error(baseContext.ErrorReadParams("name"))
+	}))
+	app.Get("/system", baseContext.Handler(func(ctx *baseContext.Context) {
+		ctx.Error(errors.New("db down"))
+	}))
+	app.Get("/panic", baseContext.Handler(func(ctx *baseContext.Context) {
+		panic("boom")
+	}))
+	return httptest.New(t, app), exporter
+}
+
+func attributeValue(attrs []attribute.KeyValue, key string) attribute.Value {
+	for _, attr := range attrs {
+		if string(attr.Key) == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestOtelServerSpan(t *testing.T) {
+	e, exporter := newOtelApp(t)
+	ids := e.GET("/users/1").Expect().Status(iris.StatusOK).Body().Raw()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("spans %d", len(spans))
+	}
+	child, server := spans[0], spans[1]
+	if server.Name != "GET /users/{id}" || attributeValue(server.Attributes, "http.route").AsString() != "/users/{id}" ||
+		attributeValue(server.Attributes, "http.response.status_code").AsInt64() != iris.StatusOK {
+		t.Fatalf("server span %s %v", server.Name, server.Attributes)
+	}
+	if child.Name != "load" || child.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Fatalf("child span %s", child.Name)
+	}
+	if ids != server.SpanContext.TraceID().String()+","+server.SpanContext.SpanID().String() {
+		t.Fatalf("ids %s", ids)
+	}
+}
+
+func TestOtelPropagation(t *testing.T) {
+	e, exporter := newOtelApp(t)
+	e.GET("/users/1").WithHeader("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01").Expect()
+
+	server := exporter.GetSpans()[1]
+	if server.SpanContext.TraceID().String() != "0af7651916cd43dd8448eb211c80319c" ||
+		server.Parent.SpanID().String() != "b7ad6b7169203331" || !server.Parent.IsRemote() {
+		t.Fatalf("server span %v parent %v", server.SpanContext, server.Parent)
+	}
+}
+
+func TestOtelErrors(t *testing.T) {
+	e, exporter := newOtelApp(t)
+
+	e.GET("/business").Expect()
+	span := exporter.GetSpans()[0]
+	if span.Status.Code != codes.Unset || len(span.Events) != 1 ||
+		attributeValue(span.Events[0].Attributes, "error.code").AsString() != "1001" {
+		t.Fatalf("business span %v %v", span.Status, span.Events)
+	}
+
+	e.GET("/system").Expect()
+	span = exporter.GetSpans()[1]
+	if span.Status.Code != codes.Error || len(span.Events) != 1 || span.Events[0].Name != "exception" {
+		t.Fatalf("system span %v %v", span.Status, span.Events)
+	}
+
+	e.GET("/panic").Expect().Status(iris.StatusInternalServerError)
+	span = exporter.GetSpans()[2]
+	if span.Status.Code != codes.Error || span.Status.Description != "panic" {
+		t.Fatalf("panic span %v", span.Status)
+	}
+}
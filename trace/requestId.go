@@ -0,0 +1,80 @@
+package trace
+
+import (
+	"context"
+	"github.com/go-tron/snowflake-id"
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+	uuidV4 "github.com/satori/go.uuid"
+	"net/http"
+)
+
+const (
+	DefaultRequestIdHeader = "X-Request-Id"
+	requestIdContextKey    = "x-request-id"
+)
+
+type Generator func() string
+
+func UUIDv4Generator() string {
+	return uuidV4.NewV4().String()
+}
+
+// UUIDv7Generator ids are time ordered, which keeps them close in indexes and logs.
+func UUIDv7Generator() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return UUIDv4Generator()
+	}
+	return id.String()
+}
+
+func ULIDGenerator() string {
+	return ulid.Make().String()
+}
+
+// SnowflakeGenerator node must be unique across the instances, e.g. from cluster.nodeName.
+func SnowflakeGenerator(node int64) Generator {
+	worker := snowflakeId.New(node)
+	return func() string {
+		return worker.Generate().String()
+	}
+}
+
+// ValidRequestId accepts ids up to maxLength made of letters, digits and "-_.:", anything else is regenerated so that it can't pollute the logs.
+func ValidRequestId(id string, maxLength int) bool {
+	if id == "" || (maxLength > 0 && len(id) > maxLength) {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// RequestId returns the request id carried by the trace context.
+func RequestId(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if v, ok := ctx.Value(requestIdContextKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// InjectRequestId copies the request id of ctx into the headers of an outgoing call, an empty name means DefaultRequestIdHeader.
+func InjectRequestId(ctx context.Context, header http.Header, name string) {
+	if name == "" {
+		name = DefaultRequestIdHeader
+	}
+	if requestId := RequestId(ctx); requestId != "" {
+		header.Set(name, requestId)
+	}
+}
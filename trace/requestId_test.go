@@ -0,0 +1,87 @@
+package trace
+
+import (
+	"github.com/go-tron/iris/baseContext"
+	"github.com/google/uuid"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/oklog/ulid/v2"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGenerators(t *testing.T) {
+	if id, err := uuid.Parse(UUIDv4Generator()); err != nil || id.Version() != 4 {
+		t.Fatalf("uuid v4 %v", err)
+	}
+	if id, err := uuid.Parse(UUIDv7Generator()); err != nil || id.Version() != 7 {
+		t.Fatalf("uuid v7 %v", err)
+	}
+	if _, err := ulid.Parse(ULIDGenerator()); err != nil {
+		t.Fatal(err)
+	}
+	generate := SnowflakeGenerator(1)
+	if first, second := generate(), generate(); first == "" || first == second {
+		t.Fatalf("snowflake ids %s %s", first, second)
+	}
+}
+
+func TestValidRequestId(t *testing.T) {
+	for id, valid := range map[string]bool{
+		"":                                     false,
+		"0af7651916cd43dd":                     true,
+		"a-b_c.d:e":                            true,
+		"a b":                                  false,
+		"a\nlevel=error":                       false,
+		strings.Repeat("a", 129):               false,
+		"f47ac10b-58cc-4372-a567-0e02b2c3d479": true,
+	} {
+		if ValidRequestId(id, 128) != valid {
+			t.Fatalf("%q valid %v", id, !valid)
+		}
+	}
+}
+
+func newRequestIdApp(t *testing.T, opts ...Option) *httptest.Expect {
+	app := iris.New()
+	app.Use(New(opts...))
+	app.Get("/", baseContext.Handler(func(ctx *baseContext.Context) {
+		header := http.Header{}
+		InjectRequestId(ctx.GetTraceCtx(), header, "")
+		if RequestId(ctx.GetTraceCtx()) != ctx.Values().GetString("requestId") || header.Get(DefaultRequestIdHeader) == "" {
+			ctx.StatusCode(iris.StatusInternalServerError)
+			return
+		}
+		ctx.WriteString(ctx.Values().GetString("requestId"))
+	}))
+	return httptest.New(t, app)
+}
+
+func TestRequestTracer(t *testing.T) {
+	e := newRequestIdApp(t)
+	resp := e.GET("/").WithHeader(DefaultRequestIdHeader, "abc-1").Expect().Status(iris.StatusOK)
+	resp.Body().IsEqual("abc-1")
+	resp.Header(DefaultRequestIdHeader).IsEqual("abc-1")
+
+	//invalid ids are replaced rather than logged
+	resp = e.GET("/").WithHeader(DefaultRequestIdHeader, "a b").Expect().Status(iris.StatusOK)
+	id := resp.Body().Raw()
+	if _, err := uuid.Parse(id); err != nil {
+		t.Fatalf("id %s", id)
+	}
+	resp.Header(DefaultRequestIdHeader).IsEqual(id)
+}
+
+func TestRequestTracerOptions(t *testing.T) {
+	e := newRequestIdApp(t,
+		WithHeader("X-Trace"),
+		WithResponseHeader(false),
+		WithGenerator(func() string { return "generated" }),
+		WithValidator(func(id string) bool { return strings.HasPrefix(id, "ok") }),
+	)
+	resp := e.GET("/").WithHeader("X-Trace", "ok1").Expect().Status(iris.StatusOK)
+	resp.Body().IsEqual("ok1")
+	resp.Header("X-Trace").IsEmpty()
+	e.GET("/").WithHeader("X-Trace", "abc").Expect().Body().IsEqual("generated")
+}
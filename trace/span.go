@@ -0,0 +1,21 @@
+package trace
+
+import (
+	"fmt"
+	"github.com/go-tron/iris/baseContext"
+)
+
+// Span runs fn within a child span of the request, the returned error is recorded on it, see baseContext.Span.
+func Span(ctx *baseContext.Context, name string, fn func(span *baseContext.Span) error) (err error) {
+	span := ctx.StartSpan(name)
+	defer func() {
+		if p := recover(); p != nil {
+			span.RecordError(fmt.Errorf("panic: %v", p))
+			span.End()
+			panic(p)
+		}
+		span.RecordError(err)
+		span.End()
+	}()
+	return fn(span)
+}
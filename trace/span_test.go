@@ -0,0 +1,45 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestSpan(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(context.Background())
+
+	app := iris.New()
+	app.Use(NewOtel(WithTracerProvider(provider)).Handler())
+	app.Get("/", baseContext.Handler(func(ctx *baseContext.Context) {
+		err := Span(ctx, "save", func(span *baseContext.Span) error {
+			return errors.New("db down")
+		})
+		func() {
+			defer func() {
+				recover()
+			}()
+			Span(ctx, "panic", func(span *baseContext.Span) error {
+				panic("boom")
+			})
+		}()
+		ctx.WriteString(err.Error())
+	}))
+	httptest.New(t, app).GET("/").Expect().Status(iris.StatusOK).Body().IsEqual("db down")
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 || spans[0].Name != "save" || spans[0].Status.Code != codes.Error {
+		t.Fatalf("spans %+v", spans)
+	}
+	if spans[1].Name != "panic" || spans[1].Status.Description != "panic: boom" {
+		t.Fatalf("panic span %+v", spans[1])
+	}
+}
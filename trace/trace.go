@@ -8,27 +8,130 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/opentracing/opentracing-go/log"
-	uuid "github.com/satori/go.uuid"
 	"github.com/uber/jaeger-client-go"
+	"go.opentelemetry.io/otel"
+	"io"
 	"reflect"
 )
 
-func New() iris.Handler {
-	return baseContext.Handler(Trace)
+type Option func(*Config)
+
+func defaultConfig() *Config {
+	return &Config{
+		Header:         DefaultRequestIdHeader,
+		ResponseHeader: true,
+		MaxLength:      128,
+		Generator:      UUIDv4Generator,
+	}
+}
+
+// WithHeader sets the header the request id is read from and echoed to.
+func WithHeader(val string) Option {
+	return func(opts *Config) {
+		opts.Header = val
+	}
+}
+func WithResponseHeader(val bool) Option {
+	return func(opts *Config) {
+		opts.ResponseHeader = val
+	}
+}
+func WithMaxLength(val int) Option {
+	return func(opts *Config) {
+		opts.MaxLength = val
+	}
+}
+func WithGenerator(val Generator) Option {
+	return func(opts *Config) {
+		opts.Generator = val
+	}
+}
+
+// WithValidator replaces ValidRequestId, invalid incoming ids are regenerated.
+func WithValidator(val func(id string) bool) Option {
+	return func(opts *Config) {
+		opts.Validator = val
+	}
+}
+
+type Config struct {
+	Header         string
+	ResponseHeader bool
+	MaxLength      int
+	Generator      Generator
+	Validator      func(id string) bool
+}
+
+type RequestTracer struct {
+	*Config
+}
+
+func NewRequestTracer(opts ...Option) *RequestTracer {
+	config := defaultConfig()
+	for _, apply := range opts {
+		apply(config)
+	}
+	if config.Header == "" {
+		panic("Header 必须设置")
+	}
+	if config.Generator == nil {
+		panic("Generator 必须设置")
+	}
+	return &RequestTracer{Config: config}
+}
+
+var defaultRequestTracer = NewRequestTracer()
+
+func New(opts ...Option) iris.Handler {
+	return NewRequestTracer(opts...).Handler()
+}
+
+func (t *RequestTracer) Handler() iris.Handler {
+	return baseContext.Handler(t.Context)
+}
+
+func (t *RequestTracer) valid(id string) bool {
+	if t.Validator != nil {
+		return t.Validator(id)
+	}
+	return ValidRequestId(id, t.MaxLength)
+}
+
+// Flush closes the global jaeger tracer and shuts down the global OpenTelemetry provider so that buffered spans are reported, used on shutdown.
+func Flush(ctx context.Context) error {
+	var err error
+	if closer, ok := opentracing.GlobalTracer().(io.Closer); ok {
+		err = closer.Close()
+	}
+	if provider, ok := otel.GetTracerProvider().(interface {
+		Shutdown(ctx context.Context) error
+	}); ok {
+		if e := provider.Shutdown(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
 }
 
 func Trace(ctx *baseContext.Context) {
+	defaultRequestTracer.Context(ctx)
+}
+
+func (t *RequestTracer) Context(ctx *baseContext.Context) {
 	//fmt.Println("Header", ctx.Request().Header)
 
-	requestId := ctx.GetHeader("x-request-id")
-	if requestId == "" {
-		requestId = uuid.NewV4().String()
+	requestId := ctx.GetHeader(t.Header)
+	if !t.valid(requestId) {
+		requestId = t.Generator()
+	}
+	if t.ResponseHeader {
+		ctx.Header(t.Header, requestId)
 	}
 
 	//fmt.Println("requestId", requestId)
 	ctx.Values().Set("requestId", requestId)
 	//r := ctx.Request()
-	traceCtx := context.WithValue(context.Background(), "x-request-id", requestId)
+	traceCtx := context.WithValue(ctx.Request().Context(), requestIdContextKey, requestId)
 
 	var opts []opentracing.StartSpanOption
 	if tracer := opentracing.GlobalTracer(); tracer != nil {
@@ -49,12 +152,15 @@ func Trace(ctx *baseContext.Context) {
 		}()
 
 		span.SetTag("x-request-id", requestId)
-		ctx.Values().Set("traceId", span.Context().(jaeger.SpanContext).TraceID())
+		//any other tracer (noop, mocks) has no jaeger span context
+		if spanCtx, ok := span.Context().(jaeger.SpanContext); ok {
+			ctx.Values().Set("traceId", spanCtx.TraceID())
+		}
 
 		//fmt.Println("traceId", span.Context().(jaeger.SpanContext).TraceID())
 		//fmt.Println("parentId", span.Context().(jaeger.SpanContext).ParentID())
 		//fmt.Println("spanId", span.Context().(jaeger.SpanContext).SpanID())
-		traceCtx = opentracing.ContextWithSpan(context.WithValue(context.Background(), "x-request-id", requestId), span)
+		traceCtx = opentracing.ContextWithSpan(traceCtx, span)
 	}
 
 	ctx.SetTraceCtx(traceCtx)
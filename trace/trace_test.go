@@ -0,0 +1,38 @@
+package trace
+
+import (
+	"context"
+	"github.com/go-tron/iris/baseContext"
+	"github.com/go-tron/iris/response"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+	"testing"
+)
+
+func init() {
+	baseContext.New("test", nil, baseContext.WithResponse(response.New()))
+}
+
+type shutdownProvider struct {
+	noop.TracerProvider
+	shutdown bool
+}
+
+func (p *shutdownProvider) Shutdown(ctx context.Context) error {
+	p.shutdown = true
+	return nil
+}
+
+func TestFlush(t *testing.T) {
+	provider := &shutdownProvider{}
+	global := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(global)
+
+	if err := Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !provider.shutdown {
+		t.Fatal("the provider was not shut down")
+	}
+}